@@ -1,5 +1,14 @@
 package ascii
 
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
 // Charset is the set of characters which ascii uses
 // to convert a pixel into an ascii character.
 //
@@ -14,3 +23,84 @@ const (
 	CharsetExtended         = ".'`^\",:;Il!i><~+_-?][}{1)(|\\/tfjrxnuvczXYUJCLQ0OZmwqpdbkhao*#MW&8%B@$"
 	CharsetBlock            = "█"
 )
+
+// CalibrateCharset creates a Charset which is ordered for the
+// given font. Each rune in the candidates is rasterised at the
+// given size and the runes are sorted by how much of their cell
+// they cover, so that the brightness increases monotonically.
+//
+// If levels is larger than 0 then the charset is thinned to that
+// many runes whose coverages are as evenly spaced as possible
+func CalibrateCharset(f *opentype.Font, pts float64, candidates Charset, levels int) (Charset, error) {
+	if levels < 0 {
+		return "", fmt.Errorf("levels cannot be smaller than 0")
+	}
+
+	pf, err := parseFont(f, pts)
+	if err != nil {
+		return "", err
+	}
+	return calibrateCharset(pf, candidates, levels)
+}
+
+// calibrateCharset orders and thins the candidates for the
+// parsed font, see CalibrateCharset
+func calibrateCharset(pf parsedFont, candidates Charset, levels int) (Charset, error) {
+	// Measure the coverage of each unique rune
+	type coverage struct {
+		r rune
+		c float64
+	}
+	var cs []coverage
+	var buf sfnt.Buffer
+	seen := make(map[rune]bool)
+	for _, r := range candidates {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+
+		if pf.fontFor(&buf, r) < 0 {
+			return "", fmt.Errorf("font has no glyph for %q (%U)", r, r)
+		}
+		cs = append(cs, coverage{r: r, c: pf.glyph(r).coverage()})
+	}
+	if len(cs) == 0 {
+		return "", fmt.Errorf("no candidate runes supplied")
+	}
+
+	sort.SliceStable(cs, func(i, j int) bool {
+		return cs[i].c < cs[j].c
+	})
+
+	// Thin the runes by choosing the rune closest to each
+	// evenly spaced coverage, while leaving enough runes
+	// after it for the remaining levels
+	if levels > 0 && levels < len(cs) {
+		lo, hi := cs[0].c, cs[len(cs)-1].c
+		thinned := make([]coverage, 0, levels)
+		next := 0
+		for k := 0; k < levels; k++ {
+			target := lo
+			if levels > 1 {
+				target += (hi - lo) * float64(k) / float64(levels-1)
+			}
+
+			best := next
+			for j := next; j <= len(cs)-(levels-k); j++ {
+				if math.Abs(cs[j].c-target) < math.Abs(cs[best].c-target) {
+					best = j
+				}
+			}
+			thinned = append(thinned, cs[best])
+			next = best + 1
+		}
+		cs = thinned
+	}
+
+	rs := make([]rune, len(cs))
+	for i, c := range cs {
+		rs[i] = c.r
+	}
+	return Charset(rs), nil
+}
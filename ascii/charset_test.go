@@ -0,0 +1,57 @@
+package ascii
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalibrateCharset(t *testing.T) {
+	pf, err := parseFont(defaultFont, 14)
+	require.Nil(t, err)
+
+	t.Run("Sorted", func(t *testing.T) {
+		c, err := CalibrateCharset(defaultFont, 14, CharsetExtended, 0)
+		require.Nil(t, err)
+		assert.ElementsMatch(t, []rune(CharsetExtended), []rune(c))
+
+		rs := []rune(c)
+		for i := 1; i < len(rs); i++ {
+			assert.LessOrEqual(t, pf.glyph(rs[i-1]).coverage(), pf.glyph(rs[i]).coverage())
+		}
+	})
+
+	t.Run("Thinned", func(t *testing.T) {
+		all, err := CalibrateCharset(defaultFont, 14, CharsetExtended, 0)
+		require.Nil(t, err)
+
+		c, err := CalibrateCharset(defaultFont, 14, CharsetExtended, 8)
+		require.Nil(t, err)
+
+		rs, allRs := []rune(c), []rune(all)
+		require.Len(t, rs, 8)
+		assert.Equal(t, allRs[0], rs[0])
+		assert.Equal(t, allRs[len(allRs)-1], rs[len(rs)-1])
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := CalibrateCharset(defaultFont, 14, "abc😀", 0)
+		assert.NotNil(t, err)
+
+		_, err = CalibrateCharset(defaultFont, 14, "", 0)
+		assert.NotNil(t, err)
+
+		_, err = CalibrateCharset(defaultFont, 14, CharsetLimited, -1)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Option", func(t *testing.T) {
+		ascii, err := ConvertWithOpts(testImg, CSet(CharsetExtended), Calibrate(16))
+		require.Nil(t, err)
+		assert.Nil(t, saveImg(ascii, "convert-calibrate.jpg"))
+
+		_, err = ConvertWithOpts(testImg, Calibrate(-1))
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	// Register the formats which can be decoded
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+
+	"github.com/fiwippi/go-ascii"
+)
+
+// formats maps the extensions of the output
+// files to the format they're written in
+var formats = map[string]string{
+	".png":  "png",
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".gif":  "gif",
+	".txt":  "txt",
+	".ans":  "ansi",
+	".html": "html",
+	".svg":  "svg",
+}
+
+// extensions maps each format to the extension
+// of the files written in a batch
+var extensions = map[string]string{
+	"png":  ".png",
+	"jpeg": ".jpg",
+	"gif":  ".gif",
+	"txt":  ".txt",
+	"ansi": ".ans",
+	"html": ".html",
+	"svg":  ".svg",
+}
+
+// conversion converts images to the output format
+// using the same options for every image
+type conversion struct {
+	format string
+	opts   []ascii.Option
+	c      *ascii.Converter
+
+	// Text is written using the number
+	// of columns and rows from the flags
+	cols, rows int
+	ansi       ascii.ANSIMode
+
+	interpolate bool
+	progress    bool
+}
+
+// newConversion creates a conversion from the flags, if format
+// is empty then it's chosen from the extension of the output
+func newConversion(f *flags, format, output string) (*conversion, error) {
+	if format == "" {
+		switch {
+		case output == "-":
+			format = "ansi"
+		case filepath.Ext(output) == "":
+			// Directories are written as images by default
+			format = "png"
+		default:
+			format = formats[strings.ToLower(filepath.Ext(output))]
+			if format == "" {
+				return nil, fmt.Errorf("unknown output format: %s", output)
+			}
+		}
+	}
+	if format == "jpg" {
+		format = "jpeg"
+	}
+	if _, found := extensions[format]; !found {
+		return nil, fmt.Errorf("invalid format: %s", format)
+	}
+
+	opts, err := f.options(&ascii.Memory{})
+	if err != nil {
+		return nil, err
+	}
+	mode, err := f.ansiMode()
+	if err != nil {
+		return nil, err
+	}
+
+	// The font is only parsed once for every image
+	c, err := ascii.NewConverter(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conversion{
+		format:      format,
+		opts:        opts,
+		c:           c,
+		cols:        f.cols,
+		rows:        f.rows,
+		ansi:        mode,
+		interpolate: f.interpolate,
+		progress:    f.progress,
+	}, nil
+}
+
+// file converts the image at src and writes it to dst,
+// - reads from stdin and writes to stdout
+func (c *conversion) file(src, dst string) error {
+	var r io.Reader = os.Stdin
+	if src != "-" {
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var w io.Writer = os.Stdout
+	if dst != "-" {
+		f, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := c.convert(r, bw, c.progress); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// batch converts every image in the src directory and writes
+// them to the dst directory using a pool of workers, if the
+// images are interpolated they are converted in order instead
+func (c *conversion) batch(src, dst string, jobs int, overwrite bool) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	if c.interpolate || jobs < 1 {
+		jobs = 1
+	}
+
+	var paths []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			paths = append(paths, e.Name())
+		}
+	}
+
+	var mu sync.Mutex
+	var done, failed int
+	convert := func(name string) {
+		out := filepath.Join(dst, strings.TrimSuffix(name, filepath.Ext(name))+extensions[c.format])
+		err := c.batchFile(filepath.Join(src, name), out, overwrite)
+
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		switch {
+		case err == image.ErrFormat:
+			log.Printf("skipping %s: not an image", name)
+		case err != nil:
+			failed++
+			log.Printf("failed converting %s: %s", name, err)
+		case c.progress:
+			fmt.Fprintf(os.Stderr, "converted %s (%d/%d)\n", name, done, len(paths))
+		}
+	}
+
+	names := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				convert(name)
+			}
+		}()
+	}
+	for _, name := range paths {
+		names <- name
+	}
+	close(names)
+	wg.Wait()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images failed to convert", failed, len(paths))
+	}
+	return nil
+}
+
+func (c *conversion) batchFile(src, dst string, overwrite bool) error {
+	if exists(dst) && !overwrite {
+		return fmt.Errorf("%s already exists, use -y to overwrite it", dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var out bytes.Buffer
+	if err := c.convert(in, &out, false); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, out.Bytes(), 0644)
+}
+
+// convert decodes the image from r and writes it to w in the
+// conversion's format. GIFs written as GIFs keep their frames
+func (c *conversion) convert(r io.Reader, w io.Writer, progress bool) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	img, kind, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	opts := c.opts
+	converter := c.c
+	if progress {
+		opts = append(opts[:len(opts):len(opts)], ascii.Progress(func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\r%d/%d rows", done, total)
+			if done == total {
+				fmt.Fprintln(os.Stderr)
+			}
+		}))
+		converter, err = ascii.NewConverter(opts...)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch c.format {
+	case "png", "jpeg":
+		asciiImg, err := converter.Convert(img)
+		if err != nil {
+			return err
+		}
+		if c.format == "png" {
+			return png.Encode(w, asciiImg)
+		}
+		return jpeg.Encode(w, asciiImg, nil)
+	case "gif":
+		if kind != "gif" {
+			asciiImg, err := converter.Convert(img)
+			if err != nil {
+				return err
+			}
+			return gif.Encode(w, asciiImg, nil)
+		}
+
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		asciiGIF, err := ascii.ConvertGIF(g, opts...)
+		if err != nil {
+			return err
+		}
+		return gif.EncodeAll(w, asciiGIF)
+	case "txt", "ansi":
+		cols, rows := textSize(img.Bounds(), c.cols, c.rows)
+		if c.format == "ansi" {
+			s, err := ascii.ConvertANSI(img, cols, rows, c.ansi, opts...)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, s)
+			return err
+		}
+
+		text, err := ascii.ConvertText(img, cols, rows, opts...)
+		if err != nil {
+			return err
+		}
+		for _, line := range text {
+			if _, err := fmt.Fprintln(w, string(line)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "html":
+		s, err := converter.ConvertHTML(img)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, s)
+		return err
+	default:
+		s, err := converter.ConvertSVG(img)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, s)
+		return err
+	}
+}
+
+// textSize returns the number of columns and rows of text for
+// the image, if either is 0 then it is chosen so that the text
+// keeps the image's aspect ratio given characters are about
+// twice as tall as they are wide
+func textSize(bounds image.Rectangle, cols, rows int) (int, int) {
+	aspect := float64(bounds.Dy()) / float64(bounds.Dx()) / 2
+	switch {
+	case cols == 0 && rows == 0:
+		cols = 80
+		fallthrough
+	case rows == 0:
+		rows = int(math.Max(1, math.Round(float64(cols)*aspect)))
+	case cols == 0:
+		cols = int(math.Max(1, math.Round(float64(rows)/aspect)))
+	}
+	return cols, rows
+}
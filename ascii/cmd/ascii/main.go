@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+)
+
+func main() {
+	// Create Flags
+	src := flag.String("i", "-", "path to the image or directory of images to convert, - reads from stdin")
+	format := flag.String("format", "", "output format: png, jpeg, gif, txt, ansi, html or svg (default from the output's extension)")
+	jobs := flag.Int("jobs", runtime.NumCPU(), "number of images converted at once when converting a directory")
+	overwrite := flag.Bool("y", false, "automatically overwrites the output file if it exists")
+	f := registerFlags(flag.CommandLine)
+
+	// Parse flags
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: ./ascii [flags] -i in.jpg [out.png]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       ./ascii [flags] -i in/ out/\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	output := "-"
+	if len(flag.Args()) > 0 {
+		output = flag.Args()[0]
+	}
+
+	// Directories are converted in batches
+	info, err := os.Stat(*src)
+	batch := err == nil && info.IsDir()
+	if batch && output == "-" {
+		fmt.Println("Output directory not specified!")
+		os.Exit(1)
+	}
+
+	// Check if overwrite
+	if !batch && output != "-" {
+		if err := checkOverwrite(*src, output, *overwrite, os.Stdin, os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	// Perform the conversion
+	c, err := newConversion(f, *format, output)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if batch {
+		err = c.batch(*src, output, *jobs, *overwrite)
+	} else {
+		err = c.file(*src, output)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// checkOverwrite asks whether output should be overwritten if it
+// exists, reading the answer from r. If the image is read from
+// stdin then it can't also be the answer so -y is needed instead
+func checkOverwrite(src, output string, overwrite bool, r io.Reader, w io.Writer) error {
+	if overwrite || !exists(output) {
+		return nil
+	}
+	if src == "-" {
+		return fmt.Errorf("%s already exists, use -y to overwrite it", output)
+	}
+
+	scanner := bufio.NewScanner(r)
+	fmt.Fprint(w, "Would you like to overwrite the file? (y/N): ")
+	scanner.Scan()
+	if strings.ToLower(strings.TrimSpace(scanner.Text())) != "y" {
+		return fmt.Errorf("%s already exists", output)
+	}
+	return nil
+}
+
+func exists(fp string) bool {
+	if _, err := os.Stat(fp); os.IsNotExist(err) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "o.png")
+	require.Nil(t, os.WriteFile(existing, nil, 0644))
+
+	check := func(src, output string, overwrite bool, answer string) error {
+		return checkOverwrite(src, output, overwrite, strings.NewReader(answer), &bytes.Buffer{})
+	}
+
+	// Missing files and -y never prompt
+	assert.Nil(t, check("-", filepath.Join(dir, "new.png"), false, ""))
+	assert.Nil(t, check("-", existing, true, ""))
+
+	// The answer is read when the image isn't
+	assert.Nil(t, check("in.jpg", existing, false, "y\n"))
+	assert.NotNil(t, check("in.jpg", existing, false, "n\n"))
+
+	// The image on stdin is never read as the answer
+	stdin := strings.NewReader("y\n")
+	err := checkOverwrite("-", existing, false, stdin, &bytes.Buffer{})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "use -y to overwrite")
+	assert.Equal(t, 2, stdin.Len())
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font/opentype"
+
+	"github.com/fiwippi/go-ascii"
+)
+
+// flags holds the flags which are
+// converted to the ascii Option(s)
+type flags struct {
+	font      string
+	fallbacks string
+	fontsize  float64
+	charset   string
+	workers   int
+	sample    string
+	calibrate int
+	shapes    float64
+	render    string
+
+	colours     string
+	fg, bg      string
+	transparent bool
+
+	gamma                float64
+	brightness, contrast float64
+	equalise             bool
+	stretch              string
+	dither               string
+	serpentine           bool
+
+	cols, rows    int
+	width, height int
+
+	interpolate bool
+	weight      float64
+	sceneCut    float64
+
+	mask    string
+	outside string
+
+	ansi     string
+	progress bool
+}
+
+func registerFlags(fs *flag.FlagSet) *flags {
+	f := &flags{}
+	fs.StringVar(&f.font, "font", "", "path to a monospace font file (default CascadiaMono-Bold)")
+	fs.StringVar(&f.fallbacks, "fallbacks", "", "comma separated paths to fonts used for runes the font doesn't have")
+	fs.Float64Var(&f.fontsize, "fontsize", 14, "fontsize of the ascii characters")
+	fs.StringVar(&f.charset, "charset", "extended", "charset name (limited, extended or block) or the literal runes")
+	fs.IntVar(&f.workers, "workers", 1, "number of goroutines used to convert each image")
+	fs.StringVar(&f.sample, "sample", "point", "sampling of each character: point, box, gaussian or median")
+	fs.IntVar(&f.calibrate, "calibrate", -1, "calibrate the charset to the font and thin it to this many runes, 0 keeps every rune")
+	fs.Float64Var(&f.shapes, "shapes", 0, "match characters by shape for cells whose contrast is at least this (0-255), 0 disables it")
+	fs.StringVar(&f.render, "render", "charset", "how cells are drawn: charset, braille or halfblock")
+
+	fs.StringVar(&f.colours, "colours", "original", "colours of the characters: original, greyscale, inverted, monochrome, plan9 or websafe")
+	fs.StringVar(&f.fg, "fg", "#ffffff", "foreground colour of monochrome characters")
+	fs.StringVar(&f.bg, "bg", "#000000", "background colour of monochrome characters")
+	fs.BoolVar(&f.transparent, "transparent", false, "keep the transparency of the image in the background")
+
+	fs.Float64Var(&f.gamma, "gamma", 1, "gamma correction of the brightness")
+	fs.Float64Var(&f.brightness, "brightness", 0, "brightness adjustment between -1 and 1")
+	fs.Float64Var(&f.contrast, "contrast", 1, "contrast multiplier")
+	fs.BoolVar(&f.equalise, "equalise", false, "equalise the histogram of the brightness")
+	fs.StringVar(&f.stretch, "stretch", "", "stretch the brightness between the low,high percentiles, e.g. 2,98")
+	fs.StringVar(&f.dither, "dither", "", "error diffusion dithering: floyd-steinberg or atkinson")
+	fs.BoolVar(&f.serpentine, "serpentine", false, "dither every other row from right to left")
+
+	fs.IntVar(&f.cols, "cols", 0, "number of columns of characters, 0 keeps the image's size")
+	fs.IntVar(&f.rows, "rows", 0, "number of rows of characters, 0 keeps the image's aspect ratio")
+	fs.IntVar(&f.width, "width", 0, "width of the output image in pixels")
+	fs.IntVar(&f.height, "height", 0, "height of the output image in pixels")
+
+	fs.BoolVar(&f.interpolate, "interpolate", false, "interpolate the characters between the images of a directory, which are converted in order")
+	fs.Float64Var(&f.weight, "weight", 0, "weight of the previous image when interpolating, 0 uses the default")
+	fs.Float64Var(&f.sceneCut, "scenecut", 0, "brightness difference between images which resets the interpolation, 0 uses the default")
+
+	fs.StringVar(&f.mask, "mask", "", "path to an alpha or greyscale mask of the region drawn with the charset")
+	fs.StringVar(&f.outside, "outside", "source", "how cells outside of the mask are drawn: source, a colour such as #ff0000 or charset:runes")
+
+	fs.StringVar(&f.ansi, "ansi", "truecolour", "colours of ansi output: 16, 256 or truecolour")
+	fs.BoolVar(&f.progress, "progress", false, "print the progress of each image")
+	return f
+}
+
+// options converts the flags to the Option(s) they set, the
+// memory is shared between all the images converted
+func (f *flags) options(mem *ascii.Memory) ([]ascii.Option, error) {
+	opts := []ascii.Option{ascii.FontPts(f.fontsize), ascii.Workers(f.workers)}
+
+	if f.font != "" {
+		ft, err := readFont(f.font)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, ascii.Font(ft))
+	}
+	if f.fallbacks != "" {
+		var fts []*opentype.Font
+		for _, path := range strings.Split(f.fallbacks, ",") {
+			ft, err := readFont(path)
+			if err != nil {
+				return nil, err
+			}
+			fts = append(fts, ft)
+		}
+		opts = append(opts, ascii.Fallbacks(fts...))
+	}
+
+	switch f.charset {
+	case "limited":
+		opts = append(opts, ascii.CSet(ascii.CharsetLimited))
+	case "extended":
+		opts = append(opts, ascii.CSet(ascii.CharsetExtended))
+	case "block":
+		opts = append(opts, ascii.CSet(ascii.CharsetBlock))
+	default:
+		opts = append(opts, ascii.CSet(ascii.Charset(f.charset)))
+	}
+
+	samplings := map[string]ascii.Sampling{
+		"point":    ascii.SamplePoint,
+		"box":      ascii.SampleBox,
+		"gaussian": ascii.SampleGaussian,
+		"median":   ascii.SampleMedian,
+	}
+	s, found := samplings[f.sample]
+	if !found {
+		return nil, fmt.Errorf("invalid sampling: %s", f.sample)
+	}
+	opts = append(opts, ascii.Sample(s))
+
+	if f.calibrate >= 0 {
+		opts = append(opts, ascii.Calibrate(f.calibrate))
+	}
+	if f.shapes > 0 {
+		opts = append(opts, ascii.MatchShapes(f.shapes))
+	}
+
+	renders := map[string]ascii.RenderMode{
+		"charset":   ascii.RenderCharset,
+		"braille":   ascii.RenderBraille,
+		"halfblock": ascii.RenderHalfBlock,
+	}
+	r, found := renders[f.render]
+	if !found {
+		return nil, fmt.Errorf("invalid render mode: %s", f.render)
+	}
+	opts = append(opts, ascii.Render(r))
+
+	// Colours
+	var m ascii.ColourMode
+	switch f.colours {
+	case "original":
+		m = ascii.ColourOriginal
+	case "greyscale":
+		m = ascii.ColourGreyscale
+	case "inverted":
+		m = ascii.ColourInverted
+	case "monochrome":
+		fg, err := parseColour(f.fg)
+		if err != nil {
+			return nil, err
+		}
+		bg, err := parseColour(f.bg)
+		if err != nil {
+			return nil, err
+		}
+		m = ascii.ColourMonochrome(fg, bg)
+	case "plan9":
+		m = ascii.ColourPalette(palette.Plan9)
+	case "websafe":
+		m = ascii.ColourPalette(palette.WebSafe)
+	default:
+		return nil, fmt.Errorf("invalid colours: %s", f.colours)
+	}
+	opts = append(opts, ascii.Colours(m))
+	if f.transparent {
+		opts = append(opts, ascii.TransparentBackground())
+	}
+
+	// Tone
+	opts = append(opts, ascii.Gamma(f.gamma), ascii.BrightnessContrast(f.brightness, f.contrast))
+	if f.equalise {
+		opts = append(opts, ascii.Equalise())
+	}
+	if f.stretch != "" {
+		low, high, err := parsePair(f.stretch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stretch: %w", err)
+		}
+		opts = append(opts, ascii.Stretch(low, high))
+	}
+	switch f.dither {
+	case "":
+	case "floyd-steinberg":
+		opts = append(opts, ascii.Dither(ascii.DitherFloydSteinberg, f.serpentine))
+	case "atkinson":
+		opts = append(opts, ascii.Dither(ascii.DitherAtkinson, f.serpentine))
+	default:
+		return nil, fmt.Errorf("invalid dither kernel: %s", f.dither)
+	}
+
+	// Size
+	switch {
+	case f.cols > 0 || f.rows > 0:
+		opts = append(opts, ascii.Grid(f.cols, f.rows))
+	case f.width > 0 || f.height > 0:
+		opts = append(opts, ascii.Size(f.width, f.height))
+	}
+
+	if f.interpolate {
+		mem.Weight, mem.SceneCut = f.weight, f.sceneCut
+		opts = append(opts, ascii.Interpolate(mem))
+	}
+
+	if f.mask != "" {
+		mask, err := readImage(f.mask)
+		if err != nil {
+			return nil, fmt.Errorf("mask: %w", err)
+		}
+
+		var o ascii.Outside
+		switch {
+		case f.outside == "source":
+			o = ascii.OutsideSource
+		case strings.HasPrefix(f.outside, "charset:"):
+			o = ascii.OutsideCharset(ascii.Charset(strings.TrimPrefix(f.outside, "charset:")))
+		default:
+			clr, err := parseColour(f.outside)
+			if err != nil {
+				return nil, fmt.Errorf("invalid outside: %w", err)
+			}
+			o = ascii.OutsideColour(clr)
+		}
+		opts = append(opts, ascii.Mask(mask, o))
+	}
+
+	return opts, nil
+}
+
+// ansiMode returns the ANSIMode of the flag
+func (f *flags) ansiMode() (ascii.ANSIMode, error) {
+	switch f.ansi {
+	case "16":
+		return ascii.ANSI16, nil
+	case "256":
+		return ascii.ANSI256, nil
+	case "truecolour", "truecolor":
+		return ascii.ANSITrueColour, nil
+	default:
+		return 0, fmt.Errorf("invalid ansi mode: %s", f.ansi)
+	}
+}
+
+func readFont(path string) (*opentype.Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.Parse(data)
+}
+
+func readImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// parseColour parses a colour in the form #rrggbb or #rrggbbaa
+func parseColour(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 && len(hex) != 8 {
+		return nil, fmt.Errorf("colour must be #rrggbb or #rrggbbaa: %s", s)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("colour must be #rrggbb or #rrggbbaa: %s", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// parsePair parses two comma separated numbers
+func parsePair(s string) (float64, float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected two comma separated numbers: %s", s)
+	}
+
+	a, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return a, b, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions(t *testing.T) {
+	parse := func(args ...string) (*conversion, error) {
+		fs := flag.NewFlagSet("ascii", flag.ContinueOnError)
+		f := registerFlags(fs)
+		require.Nil(t, fs.Parse(args))
+		return newConversion(f, "", "out.png")
+	}
+
+	// The defaults are valid
+	c, err := parse()
+	require.Nil(t, err)
+	assert.Equal(t, "png", c.format)
+
+	_, err = parse("-render", "halfblock", "-colours", "monochrome")
+	assert.Nil(t, err)
+
+	_, err = parse("-charset", "limited", "-sample", "box", "-render", "braille", "-colours", "monochrome",
+		"-fg", "#00ff00", "-stretch", "2,98", "-dither", "atkinson", "-cols", "80", "-interpolate", "-weight", "0.5")
+	assert.Nil(t, err)
+
+	invalid := [][]string{
+		{"-sample", "nearest"},
+		{"-render", "ascii"},
+		{"-render", "halfblock", "-interpolate"},
+		{"-colours", "sepia"},
+		{"-colours", "monochrome", "-fg", "green"},
+		{"-stretch", "2"},
+		{"-dither", "bayer"},
+		{"-ansi", "8"},
+		{"-charset", "漢"},
+		{"-font", "missing.ttf"},
+	}
+	for _, args := range invalid {
+		_, err := parse(args...)
+		assert.NotNil(t, err, args)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	f := registerFlags(flag.NewFlagSet("ascii", flag.ContinueOnError))
+	for output, format := range map[string]string{
+		"-":         "ansi",
+		"out":       "png",
+		"out.JPG":   "jpeg",
+		"out.txt":   "txt",
+		"out.ans":   "ansi",
+		"out.html":  "html",
+		"out/a.svg": "svg",
+	} {
+		c, err := newConversion(f, "", output)
+		require.Nil(t, err)
+		assert.Equal(t, format, c.format)
+	}
+
+	_, err := newConversion(f, "", "out.mp4")
+	assert.NotNil(t, err)
+	_, err = newConversion(f, "bmp", "out")
+	assert.NotNil(t, err)
+}
+
+func TestParseColour(t *testing.T) {
+	clr, err := parseColour("#ff8000")
+	require.Nil(t, err)
+	assert.Equal(t, color.NRGBA{R: 255, G: 128, A: 255}, clr)
+
+	clr, err = parseColour("00000080")
+	require.Nil(t, err)
+	assert.Equal(t, color.NRGBA{A: 128}, clr)
+
+	_, err = parseColour("#fff")
+	assert.NotNil(t, err)
+}
+
+func TestTextSize(t *testing.T) {
+	b := image.Rect(0, 0, 200, 100)
+	cols, rows := textSize(b, 0, 0)
+	assert.Equal(t, [2]int{80, 20}, [2]int{cols, rows})
+
+	cols, rows = textSize(b, 0, 10)
+	assert.Equal(t, [2]int{40, 10}, [2]int{cols, rows})
+
+	cols, rows = textSize(b, 30, 7)
+	assert.Equal(t, [2]int{30, 7}, [2]int{cols, rows})
+}
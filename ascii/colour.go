@@ -0,0 +1,134 @@
+package ascii
+
+import (
+	"image/color"
+)
+
+type colourKind int
+
+const (
+	colourOriginal colourKind = iota
+	colourMonochrome
+	colourGreyscale
+	colourInverted
+	colourPalette
+)
+
+// ColourMode decides the colours which the characters and
+// the background are drawn in
+type ColourMode struct {
+	kind    colourKind
+	fg, bg  color.Color
+	palette color.Palette
+}
+
+var (
+	// ColourOriginal draws each character in the colour of
+	// the pixels it covers on a black background
+	ColourOriginal = ColourMode{kind: colourOriginal}
+	// ColourGreyscale draws each character in the greyscale
+	// colour of the pixels it covers on a black background
+	ColourGreyscale = ColourMode{kind: colourGreyscale}
+	// ColourInverted draws each character in the colour of
+	// the pixels it covers on a white background, the charset
+	// is reversed so that dark pixels use the densest runes
+	ColourInverted = ColourMode{kind: colourInverted}
+)
+
+// ColourMonochrome draws every character in the foreground
+// colour on the background colour
+func ColourMonochrome(fg, bg color.Color) ColourMode {
+	return ColourMode{kind: colourMonochrome, fg: fg, bg: bg}
+}
+
+// ColourPalette draws each character in the colour from the
+// palette which is closest to the pixels it covers on a black
+// background, e.g. to restrict the output to the CGA colours
+func ColourPalette(p color.Palette) ColourMode {
+	return ColourMode{kind: colourPalette, palette: p}
+}
+
+// valid returns whether the mode has everything it needs
+func (m ColourMode) valid() bool {
+	switch m.kind {
+	case colourMonochrome:
+		return m.fg != nil && m.bg != nil
+	case colourPalette:
+		return len(m.palette) > 0
+	default:
+		return m.kind >= colourOriginal && m.kind <= colourPalette
+	}
+}
+
+// inverted returns whether dark pixels are drawn using
+// the densest runes instead of the sparsest
+func (m ColourMode) inverted() bool {
+	return m.kind == colourInverted
+}
+
+// background returns the colour of the background
+func (m ColourMode) background() color.Color {
+	switch m.kind {
+	case colourMonochrome:
+		return m.bg
+	case colourInverted:
+		return color.White
+	default:
+		return color.Black
+	}
+}
+
+// apply changes the colours of the cells to the mode's,
+// filled cells are left as they are. If the cells have
+// their own background colours, i.e. they are half blocks,
+// then monochrome cells use the foreground or background
+// colour depending on how bright they are
+func (m ColourMode) apply(cs []cell) {
+	if m.kind == colourOriginal || m.kind == colourInverted {
+		return
+	}
+
+	for i := range cs {
+		if cs[i].filled {
+			continue
+		}
+
+		halves := cs[i].bg != nil
+		cs[i].clr = m.convert(cs[i].clr, halves)
+		if halves {
+			cs[i].bg = m.convert(cs[i].bg, halves)
+		}
+	}
+}
+
+func (m ColourMode) convert(clr color.Color, threshold bool) color.Color {
+	// Colours are premultiplied, so the new colour is scaled
+	// by the alpha of the original so transparent pixels stay
+	// transparent
+	_, _, _, a := clr.RGBA()
+
+	var c color.Color
+	switch m.kind {
+	case colourMonochrome:
+		c = m.fg
+		if threshold && brightness(clr) < 128 {
+			c = m.bg
+		}
+	case colourGreyscale:
+		// The brightness is premultiplied so it's already scaled
+		y := uint16(brightness(clr) * 0x101)
+		return color.RGBA64{R: y, G: y, B: y, A: uint16(a)}
+	case colourPalette:
+		c = m.palette.Convert(clr)
+	default:
+		return clr
+	}
+
+	r, g, b, ca := c.RGBA()
+	return color.RGBA64{
+		R: uint16(r * a / 0xffff),
+		G: uint16(g * a / 0xffff),
+		B: uint16(b * a / 0xffff),
+		A: uint16(ca * a / 0xffff),
+	}
+}
@@ -0,0 +1,103 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColours(t *testing.T) {
+	t.Run("Monochrome", func(t *testing.T) {
+		fg, bg := color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 64, 255}
+		ascii, err := ConvertWithOpts(testImg, Colours(ColourMonochrome(fg, bg)))
+		require.Nil(t, err)
+		assert.Nil(t, saveImg(ascii, "convert-colours_monochrome.jpg"))
+
+		// Every pixel is a blend of the foreground and background
+		pix := ascii.(*image.RGBA).Pix
+		mixed := false
+		for i := 0; i < len(pix); i += 4 {
+			mixed = mixed || pix[i] != 0
+		}
+		assert.False(t, mixed)
+	})
+
+	t.Run("Greyscale", func(t *testing.T) {
+		ascii, err := ConvertWithOpts(testImg, Colours(ColourGreyscale))
+		require.Nil(t, err)
+		assert.Nil(t, saveImg(ascii, "convert-colours_greyscale.jpg"))
+
+		pix := ascii.(*image.RGBA).Pix
+		grey := true
+		for i := 0; i < len(pix); i += 4 {
+			grey = grey && pix[i] == pix[i+1] && pix[i] == pix[i+2]
+		}
+		assert.True(t, grey)
+	})
+
+	t.Run("Inverted", func(t *testing.T) {
+		ascii, err := ConvertWithOpts(testImg, Colours(ColourInverted))
+		require.Nil(t, err)
+		assert.Nil(t, saveImg(ascii, "convert-colours_inverted.jpg"))
+
+		// The bottom row of cells is never drawn on
+		b := ascii.Bounds()
+		assert.Equal(t, color.RGBA{255, 255, 255, 255}, ascii.At(b.Max.X-1, b.Max.Y-1))
+	})
+
+	t.Run("Palette", func(t *testing.T) {
+		ascii, err := ConvertWithOpts(testImg, Colours(ColourPalette(palette.Plan9)))
+		require.Nil(t, err)
+		assert.Nil(t, saveImg(ascii, "convert-colours_palette.jpg"))
+
+		cs, _, err := textCells(testImg, 40, 20, Colours(ColourPalette(palette.Plan9)))
+		require.Nil(t, err)
+		for _, c := range cs {
+			assert.Equal(t, c.clr, color.RGBA64Model.Convert(color.Palette(palette.Plan9).Convert(c.clr)))
+		}
+	})
+
+	t.Run("Transparent", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+		for i := range img.Pix {
+			img.Pix[i] = 255
+		}
+		for y := 0; y < 50; y++ {
+			for x := 0; x < 100; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{255, 255, 255, 0})
+			}
+		}
+
+		// Glyphs overflow the top of their cell so only
+		// the pixels well above the opaque half are checked
+		ascii, err := ConvertWithOpts(img, TransparentBackground())
+		require.Nil(t, err)
+		for y := 0; y < 30; y++ {
+			for x := 0; x < 100; x++ {
+				require.Equal(t, uint32(0), alpha(ascii.At(x, y)))
+			}
+		}
+		assert.Equal(t, uint32(0xffff), alpha(ascii.At(99, 99)))
+
+		ascii, err = ConvertWithOpts(img)
+		require.Nil(t, err)
+		assert.Equal(t, uint32(0xffff), alpha(ascii.At(0, 0)))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertWithOpts(testImg, Colours(ColourPalette(nil)))
+		assert.NotNil(t, err)
+
+		_, err = ConvertWithOpts(testImg, Colours(ColourMonochrome(nil, color.Black)))
+		assert.NotNil(t, err)
+	})
+}
+
+func alpha(c color.Color) uint32 {
+	_, _, _, a := c.RGBA()
+	return a
+}
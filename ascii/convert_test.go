@@ -6,6 +6,7 @@ import (
 	"image"
 	"image/jpeg"
 	"log"
+	"math"
 	"os"
 	"testing"
 )
@@ -86,6 +87,11 @@ func TestValidOptions(t *testing.T) {
 		_, err := ConvertWithOpts(testImg, Interpolate(mem))
 		assert.Nil(t, err)
 	})
+
+	t.Run("Workers", func(t *testing.T) {
+		_, err := ConvertWithOpts(testImg, Workers(4))
+		assert.Nil(t, err)
+	})
 }
 
 func TestInvalidOptions(t *testing.T) {
@@ -106,4 +112,58 @@ func TestInvalidOptions(t *testing.T) {
 		_, err := ConvertWithOpts(testImg, Interpolate(nil))
 		assert.NotNil(t, err)
 	})
+
+	t.Run("Workers", func(t *testing.T) {
+		_, err := ConvertWithOpts(testImg, Workers(0))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestWorkers(t *testing.T) {
+	serialMem, parallelMem := &Memory{}, &Memory{}
+	for _, pts := range []float64{8, 14, 40} {
+		serial, err := ConvertWithOpts(testImg, FontPts(pts), Interpolate(serialMem))
+		require.Nil(t, err)
+
+		parallel, err := ConvertWithOpts(testImg, FontPts(pts), Interpolate(parallelMem), Workers(7))
+		require.Nil(t, err)
+
+		assert.Equal(t, serial.(*image.RGBA).Pix, parallel.(*image.RGBA).Pix)
+	}
+}
+
+func TestOutputSize(t *testing.T) {
+	pf, err := parseFont(defaultFont, 14)
+	require.Nil(t, err)
+
+	t.Run("Grid", func(t *testing.T) {
+		ascii, err := ConvertWithOpts(testImg, Grid(80, 0))
+		require.Nil(t, err)
+		assert.Nil(t, saveImg(ascii, "convert-grid.jpg"))
+
+		// The source is 2:3 and characters are 8x13
+		rows := int(math.Round(80 * 1.5 * 8 / 13))
+		assert.Equal(t, image.Rect(0, 0, 80*pf.width, rows*pf.height), ascii.Bounds())
+
+		ascii, err = ConvertWithOpts(testImg, Grid(40, 20))
+		require.Nil(t, err)
+		assert.Equal(t, image.Rect(0, 0, 40*pf.width, 20*pf.height), ascii.Bounds())
+	})
+
+	t.Run("Size", func(t *testing.T) {
+		ascii, err := ConvertWithOpts(testImg, Size(800, 0))
+		require.Nil(t, err)
+		assert.Equal(t, image.Rect(0, 0, 800, 1200), ascii.Bounds())
+
+		ascii, err = ConvertWithOpts(testImg, Size(0, 600), TransparentBackground())
+		require.Nil(t, err)
+		assert.Equal(t, image.Rect(0, 0, 400, 600), ascii.Bounds())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, o := range []Option{Grid(0, 0), Grid(-1, 10), Size(0, 0), Size(10, -1)} {
+			_, err := ConvertWithOpts(testImg, o)
+			assert.NotNil(t, err)
+		}
+	})
 }
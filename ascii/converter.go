@@ -0,0 +1,360 @@
+package ascii
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Converter renders images using ascii characters with a
+// fixed set of Option(s). The font is parsed and the glyphs
+// of the charset are rasterised once when the Converter is
+// created, which makes it much faster than ConvertWithOpts
+// when converting many images such as the frames of a video.
+//
+// A Converter is safe for concurrent use by multiple goroutines
+type Converter struct {
+	opts   *options
+	pf     parsedFont
+	glyphs map[rune]glyph
+	shapes *shapes
+
+	// The size of each cell in pixels and where the dot
+	// is placed relative to the top-left of the cell
+	cellW, cellH int
+	dot          image.Point
+
+	// If clip is set then glyphs are clipped to their cell,
+	// otherwise they can overflow it. The vertical extent
+	// of what is drawn relative to the top of the cell is
+	// between top and bottom
+	clip        bool
+	top, bottom int
+}
+
+// NewConverter creates a Converter which renders images
+// using the given Option(s)
+func NewConverter(opts ...Option) (*Converter, error) {
+	o, err := newOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newConverter(o)
+}
+
+func newConverter(opts *options) (*Converter, error) {
+	// Every rune which could be drawn is checked
+	// so that a charset with runes missing from
+	// the font doesn't silently draw nothing
+	drawn := opts.render.runes(opts.charset)
+	if opts.mask != nil {
+		drawn = append(drawn, []rune(opts.outside.charset)...)
+	}
+	pf, err := parseFonts(opts.font, opts.fallbacks, opts.fontPts, drawn...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rasterise every rune which could be drawn
+	glyphs := make(map[rune]glyph)
+	for _, r := range drawn {
+		if _, found := glyphs[r]; !found {
+			glyphs[r] = pf.glyph(r)
+		}
+	}
+
+	c := &Converter{
+		opts:   opts,
+		pf:     pf,
+		glyphs: glyphs,
+	}
+	if opts.matchShapes && opts.render == RenderCharset {
+		c.shapes = newShapes(pf, opts.charset, opts.shapeThreshold)
+	}
+
+	if opts.render == RenderCharset {
+		// Cells are the width of a character and the height
+		// of its ascent, the characters are drawn above the
+		// top of their cell and can overflow it
+		c.cellW, c.cellH = pf.width, pf.height
+		for _, g := range glyphs {
+			if g.dr.Min.Y < c.top {
+				c.top = g.dr.Min.Y
+			}
+			if g.dr.Max.Y > c.bottom {
+				c.bottom = g.dr.Max.Y
+			}
+		}
+	} else {
+		// Cells are exactly the size of a full block so
+		// that the sub-cell samples line up with where
+		// they are drawn and the cells tile seamlessly
+		block := pf.glyph(fullBlock).dr
+		c.cellW, c.cellH = block.Dx(), block.Dy()
+		c.dot = block.Min.Mul(-1)
+		c.clip = true
+		c.top, c.bottom = 0, c.cellH
+	}
+
+	return c, nil
+}
+
+// Convert renders the given image using ascii characters
+func (c *Converter) Convert(img image.Image) (image.Image, error) {
+	return c.ConvertContext(context.Background(), img)
+}
+
+// ConvertContext renders the given image using ascii characters,
+// the context is checked between each row of characters and if
+// it is done then its error is returned
+func (c *Converter) ConvertContext(ctx context.Context, img image.Image) (image.Image, error) {
+	// Ensure image exists
+	if img == nil {
+		return nil, fmt.Errorf("image cannot be nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Every row is sampled and then drawn
+	newImg, _, err := c.draw(img, c.newProgress(ctx, img.Bounds(), 2))
+	if err != nil {
+		return nil, err
+	}
+	return newImg, nil
+}
+
+// Frame is an image which is being converted in steps, so
+// that the steps of converting many images such as the frames
+// of a video can be pipelined. Images are converted by calling
+// Sample, Map and then Draw, which is what Convert does
+type Frame struct {
+	img    image.Image
+	canvas image.Rectangle
+	g      grid
+	s      *samples
+	cs     []cell
+	prog   *progress
+}
+
+// Sample samples the colour and brightness of every cell of
+// the image, which is the expensive part of working out the
+// cells. It doesn't use the Memory so many images can be
+// sampled concurrently even if they are interpolated
+func (c *Converter) Sample(img image.Image) (*Frame, error) {
+	// Ensure image exists
+	if img == nil {
+		return nil, fmt.Errorf("image cannot be nil")
+	}
+	return c.sample(img, c.newProgress(context.Background(), img.Bounds(), 2))
+}
+
+// Map maps the sampled cells of the frame to their runes and
+// colours. If the images are interpolated this uses the Memory,
+// so the frames must be mapped in the order of their images
+func (c *Converter) Map(f *Frame) error {
+	if f == nil || f.s == nil {
+		return fmt.Errorf("frame must be sampled before it's mapped")
+	}
+	if f.cs != nil {
+		return fmt.Errorf("frame has already been mapped")
+	}
+	c.mapFrame(f)
+	return nil
+}
+
+// Draw draws the mapped cells of the frame, which is the other
+// expensive part of converting an image so many frames can be
+// drawn concurrently
+func (c *Converter) Draw(f *Frame) (image.Image, error) {
+	if f == nil || f.cs == nil {
+		return nil, fmt.Errorf("frame must be mapped before it's drawn")
+	}
+	return c.render(f)
+}
+
+// draw renders the image and returns the cells it drew, each
+// row is marked as processed once when it's sampled and again
+// when it's drawn
+func (c *Converter) draw(img image.Image, prog *progress) (*image.RGBA, []cell, error) {
+	f, err := c.sample(img, prog)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.mapFrame(f)
+
+	newImg, err := c.render(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newImg, f.cs, nil
+}
+
+// sample creates a frame of the image and samples its cells
+func (c *Converter) sample(img image.Image, prog *progress) (*Frame, error) {
+	canvas, src, dst := c.layout(img.Bounds())
+	s, err := sample(img, src, c.opts, c.shapes != nil, prog)
+	if err != nil {
+		return nil, err
+	}
+	return &Frame{img: img, canvas: canvas, g: dst, s: s, prog: prog}, nil
+}
+
+// mapFrame works out the runes of the frame's cells
+func (c *Converter) mapFrame(f *Frame) {
+	f.cs = f.s.cells(c.opts, c.shapes)
+}
+
+// render draws the cells of the frame
+func (c *Converter) render(f *Frame) (*image.RGBA, error) {
+	img, canvas, g, cs, prog := f.img, f.canvas, f.g, f.cs, f.prog
+
+	// Create the new image
+	bounds := img.Bounds()
+	newImg := image.NewRGBA(canvas)
+	bg := image.NewUniform(c.opts.colours.background())
+	if c.opts.transparent {
+		// The original image's alpha channel is used as
+		// a mask so that transparent pixels stay that way
+		var mask image.Image = img
+		if canvas != bounds {
+			scaled := image.NewAlpha(canvas)
+			xdraw.ApproxBiLinear.Scale(scaled, canvas, img, bounds, draw.Src, nil)
+			mask = scaled
+		}
+		draw.DrawMask(newImg, canvas, bg, image.Point{}, mask, canvas.Min, draw.Src)
+	} else {
+		draw.Draw(newImg, canvas, bg, image.Point{}, draw.Src)
+	}
+
+	// Filled cells may copy the pixels of the original
+	// image, which has to be the same size as the canvas
+	var src image.Image = img
+	if c.opts.mask != nil && c.opts.outside.kind == outsideSource && canvas != bounds {
+		scaled := image.NewRGBA(canvas)
+		xdraw.ApproxBiLinear.Scale(scaled, canvas, img, bounds, draw.Src, nil)
+		src = scaled
+	}
+
+	// Draw the runes, each band owns the pixels from the
+	// top of its first row of cells to the top of the next
+	// band. Glyphs which overflow into a band from other rows
+	// are clipped to it and drawn in the same order as they
+	// would be serially so the output is always the same
+	bands(c.opts.workers, g.rows, func(start, end int) {
+		y0, y1 := g.origin(0, start).Y, canvas.Max.Y
+		if end < g.rows {
+			y1 = g.origin(0, end).Y
+		}
+		dst := newImg.SubImage(image.Rect(canvas.Min.X, y0, canvas.Max.X, y1)).(*image.RGBA)
+
+		for row := 0; row < g.rows; row++ {
+			y := g.origin(0, row).Y
+			if y+c.bottom <= y0 || y+c.top >= y1 {
+				continue
+			}
+
+			for col := 0; col < g.cols; col++ {
+				cl := cs[row*g.cols+col]
+				p := g.origin(col, row)
+
+				r := image.Rect(p.X, p.Y, p.X+c.cellW, p.Y+c.cellH).Intersect(dst.Bounds())
+				switch {
+				case cl.filled && c.opts.outside.kind == outsideSource:
+					draw.Draw(dst, r, src, r.Min, draw.Src)
+				case cl.bg != nil:
+					draw.Draw(dst, r, image.NewUniform(cl.bg), image.Point{}, draw.Src)
+				}
+
+				clip := dst.Bounds()
+				if c.clip {
+					clip = r
+				}
+				c.glyphs[cl.r].draw(dst, cl.clr, p.Add(c.dot), clip)
+			}
+
+			// Rows which overflow into other bands
+			// are only counted by their own band
+			if row >= start && row < end && prog.row() != nil {
+				return
+			}
+		}
+	})
+	if err := prog.failed(); err != nil {
+		return nil, err
+	}
+
+	return newImg, nil
+}
+
+// cells works out the runes of the cells from the source
+// image, it returns the bounds of the rendered image and the
+// grid which the cells are drawn on. Every output format uses
+// the same cells so that they all agree
+func (c *Converter) cells(img image.Image, p *progress) (image.Rectangle, grid, []cell, error) {
+	f, err := c.sample(img, p)
+	if err != nil {
+		return image.Rectangle{}, grid{}, nil, err
+	}
+	c.mapFrame(f)
+	return f.canvas, f.g, f.cs, nil
+}
+
+// newProgress creates the progress of a conversion which
+// processes each row of the image's cells the given number
+// of times
+func (c *Converter) newProgress(ctx context.Context, bounds image.Rectangle, passes int) *progress {
+	_, _, g := c.layout(bounds)
+	return newProgress(ctx, c.opts.progress, passes*g.rows)
+}
+
+// layout returns the bounds of the rendered image and how the
+// source image and rendered image are divided into cells. By
+// default the rendered image is the same size as the source
+// and each cell is the size of a character
+func (c *Converter) layout(bounds image.Rectangle) (canvas image.Rectangle, src, dst grid) {
+	w, h := bounds.Dx(), bounds.Dy()
+	switch {
+	case c.opts.cols > 0 || c.opts.rows > 0:
+		// Characters are taller than they are wide so
+		// there are fewer rows than there are columns
+		// for an image of the same height and width
+		cols, rows := c.opts.cols, c.opts.rows
+		aspect := float64(h) / float64(w) * float64(c.cellW) / float64(c.cellH)
+		if rows == 0 {
+			rows = maxInt(1, int(math.Round(float64(cols)*aspect)))
+		}
+		if cols == 0 {
+			cols = maxInt(1, int(math.Round(float64(rows)/aspect)))
+		}
+
+		canvas = image.Rect(0, 0, cols*c.cellW, rows*c.cellH)
+		dst = fixedGrid(canvas, c.cellW, c.cellH)
+	case c.opts.width > 0 || c.opts.height > 0:
+		width, height := c.opts.width, c.opts.height
+		if height == 0 {
+			height = maxInt(1, int(math.Round(float64(width*h)/float64(w))))
+		}
+		if width == 0 {
+			width = maxInt(1, int(math.Round(float64(height*w)/float64(h))))
+		}
+
+		canvas = image.Rect(0, 0, width, height)
+		dst = fixedGrid(canvas, c.cellW, c.cellH)
+	default:
+		return bounds, fixedGrid(bounds, c.cellW, c.cellH), fixedGrid(bounds, c.cellW, c.cellH)
+	}
+
+	return canvas, fittedGrid(bounds, dst.cols, dst.rows), dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,124 @@
+package ascii
+
+import (
+	"image"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverter(t *testing.T) {
+	c, err := NewConverter(FontPts(20), CSet(CharsetLimited))
+	require.Nil(t, err)
+
+	t.Run("MatchesConvertWithOpts", func(t *testing.T) {
+		expected, err := ConvertWithOpts(testImg, FontPts(20), CSet(CharsetLimited))
+		require.Nil(t, err)
+
+		actual, err := c.Convert(testImg)
+		require.Nil(t, err)
+		assert.Equal(t, expected.(*image.RGBA).Pix, actual.(*image.RGBA).Pix)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		mem := &Memory{}
+		c, err := NewConverter(Interpolate(mem))
+		require.Nil(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.Convert(testImg)
+				assert.Nil(t, err)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := NewConverter(FontPts(0))
+		assert.NotNil(t, err)
+
+		_, err = c.Convert(nil)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestFrames(t *testing.T) {
+	// Different parts of the test image are used as frames
+	type subImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+	var frames []image.Image
+	for i := 0; i < 6; i++ {
+		r := image.Rect(0, 0, 1000, 1000).Add(image.Pt(i*250, i*400))
+		frames = append(frames, testImg.(subImager).SubImage(r))
+	}
+
+	// Convert the frames in order, scene cuts aren't
+	// detected so that every frame is interpolated
+	var expected []image.Image
+	c, err := NewConverter(Grid(60, 0), Interpolate(&Memory{SceneCut: -1}))
+	require.Nil(t, err)
+	for _, frame := range frames {
+		img, err := c.Convert(frame)
+		require.Nil(t, err)
+		expected = append(expected, img)
+	}
+
+	// Sample and draw the frames concurrently
+	// but map them in order
+	c, err = NewConverter(Grid(60, 0), Interpolate(&Memory{SceneCut: -1}))
+	require.Nil(t, err)
+
+	sampled := make([]*Frame, len(frames))
+	var wg sync.WaitGroup
+	for i := range frames {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f, err := c.Sample(frames[i])
+			assert.Nil(t, err)
+			sampled[i] = f
+		}(i)
+	}
+	wg.Wait()
+
+	for _, f := range sampled {
+		require.Nil(t, c.Map(f))
+	}
+
+	actual := make([]image.Image, len(frames))
+	for i := range frames {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			img, err := c.Draw(sampled[i])
+			assert.Nil(t, err)
+			actual[i] = img
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range frames {
+		assert.Equal(t, expected[i].(*image.RGBA).Pix, actual[i].(*image.RGBA).Pix)
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := c.Sample(nil)
+		assert.NotNil(t, err)
+
+		f, err := c.Sample(frames[0])
+		require.Nil(t, err)
+		_, err = c.Draw(f)
+		assert.NotNil(t, err)
+
+		require.Nil(t, c.Map(f))
+		assert.NotNil(t, c.Map(f))
+		assert.NotNil(t, c.Map(nil))
+	})
+}
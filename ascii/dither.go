@@ -0,0 +1,86 @@
+package ascii
+
+import (
+	"math"
+)
+
+// DitherKernel is the error diffusion kernel which spreads
+// the error of mapping a brightness to a rune onto the
+// neighbouring characters
+type DitherKernel int
+
+const (
+	// DitherFloydSteinberg spreads all of the error
+	// onto the 4 characters to the right and below
+	DitherFloydSteinberg DitherKernel = iota
+	// DitherAtkinson spreads 3/4 of the error onto the
+	// 6 characters to the right and below, which keeps
+	// more contrast than Floyd-Steinberg
+	DitherAtkinson
+)
+
+type ditherWeight struct {
+	dx, dy int
+	w      float64
+}
+
+var ditherKernels = map[DitherKernel][]ditherWeight{
+	DitherFloydSteinberg: {
+		{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16},
+	},
+	DitherAtkinson: {
+		{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8}, {-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8}, {0, 2, 1.0 / 8},
+	},
+}
+
+// ditherer maps brightnesses to the indices of
+// runes using error diffusion
+type ditherer struct {
+	kernel     DitherKernel
+	serpentine bool
+}
+
+// indices maps the brightnesses of the cols by rows cells to
+// the indices of the runes in a charset of length n. The cells
+// are always processed in the same order so the result for a
+// given input is deterministic
+func (d ditherer) indices(bs []float64, cols, rows, n int) []int {
+	indices := make([]int, len(bs))
+	if n < 2 {
+		return indices
+	}
+
+	step := 255 / float64(n-1)
+	errs := make([]float64, len(bs))
+	for row := 0; row < rows; row++ {
+		// Serpentine scans go right to left on odd rows
+		// so the kernel is mirrored for them
+		reverse := d.serpentine && row%2 == 1
+		for c := 0; c < cols; c++ {
+			col := c
+			if reverse {
+				col = cols - 1 - c
+			}
+
+			i := row*cols + col
+			b := clamp(bs[i]+errs[i], 0, 255)
+			q := int(math.Round(b / step))
+			indices[i] = q
+
+			e := b - float64(q)*step
+			for _, w := range ditherKernels[d.kernel] {
+				dx := w.dx
+				if reverse {
+					dx = -dx
+				}
+
+				x, y := col+dx, row+w.dy
+				if x >= 0 && x < cols && y < rows {
+					errs[y*cols+x] += e * w.w
+				}
+			}
+		}
+	}
+
+	return indices
+}
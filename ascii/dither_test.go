@@ -0,0 +1,64 @@
+package ascii
+
+import (
+	"image"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDither(t *testing.T) {
+	// A flat grey which lies between two runes of the charset
+	img := image.NewGray(image.Rect(0, 0, 400, 400))
+	for i := range img.Pix {
+		img.Pix[i] = 70
+	}
+
+	// meanBrightness returns the mean brightness which the runes represent
+	meanBrightness := func(text [][]rune) float64 {
+		var sum float64
+		for _, row := range text {
+			for _, r := range row {
+				sum += float64(strings.IndexRune(string(CharsetLimited), r)) * 255 / float64(len(CharsetLimited)-1)
+			}
+		}
+		return sum / float64(len(text)*len(text[0]))
+	}
+
+	plain, err := ConvertText(img, 40, 40, CSet(CharsetLimited))
+	require.Nil(t, err)
+	assert.Equal(t, 1, uniqueRunes(plain))
+
+	for _, k := range []DitherKernel{DitherFloydSteinberg, DitherAtkinson} {
+		for _, serpentine := range []bool{false, true} {
+			text, err := ConvertText(img, 40, 40, CSet(CharsetLimited), Dither(k, serpentine))
+			require.Nil(t, err)
+			assert.Greater(t, uniqueRunes(text), 1)
+			assert.Less(t, math.Abs(meanBrightness(text)-70), math.Abs(meanBrightness(plain)-70))
+		}
+	}
+
+	t.Run("Deterministic", func(t *testing.T) {
+		memA, memB := &Memory{}, &Memory{}
+		for i := 0; i < 3; i++ {
+			a, err := ConvertWithOpts(testImg, Dither(DitherFloydSteinberg, true), Interpolate(memA), Workers(4))
+			require.Nil(t, err)
+
+			b, err := ConvertWithOpts(testImg, Dither(DitherFloydSteinberg, true), Interpolate(memB))
+			require.Nil(t, err)
+			require.Equal(t, a.(*image.RGBA).Pix, b.(*image.RGBA).Pix)
+
+			if i == 0 {
+				assert.Nil(t, saveImg(a, "convert-dither.jpg"))
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertWithOpts(img, Dither(DitherKernel(-1), false))
+		assert.NotNil(t, err)
+	})
+}
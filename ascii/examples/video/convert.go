@@ -1,114 +1,246 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
 	"image"
-	"image/png"
+	"image/draw"
 	"io"
-	"log"
+	"math"
 	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/theckman/yacspin"
-
 	"github.com/fiwippi/go-ascii"
-	"video/internal/parse"
 )
 
-func Convert(ctx context.Context, src, dst string, fontsize float64, args ...string) error {
-	imgD, ffDuration, ffProgress, errD := decode(ctx, src)
-	imgE, errE := encode(ctx, dst, args...)
+// options configure how the video is converted
+type options struct {
+	fontsize float64
+	workers  int
+	audio    audio
+
+	// The video is trimmed to start and end, an end of 0 is
+	// the end of the video. The frames are also converted at
+	// the fps and width if they're set, which happens before
+	// the conversion so fewer frames have to be converted
+	start, end time.Duration
+	fps        float64
+	width      int
+
+	progressJSON io.Writer // Progress is written as JSON lines if set
+	args         []string  // Extra args for the encoder
+}
+
+// output returns the info of the video once it's been trimmed,
+// had its frame rate changed and been scaled
+func (o options) output(i info) (info, error) {
+	if o.end != 0 && o.end <= o.start {
+		return info{}, fmt.Errorf("end of the video must be after its start")
+	}
+	if i.duration != 0 && o.start >= i.duration {
+		return info{}, fmt.Errorf("start of the video must be before its end (%s)", i.duration)
+	}
+
+	end := i.duration
+	if o.end != 0 && (end == 0 || o.end < end) {
+		end = o.end
+	}
+	if end != 0 {
+		i.duration = end - o.start
+	}
+
+	if o.fps > 0 {
+		i.rate = strconv.FormatFloat(o.fps, 'f', -1, 64)
+	}
+	if o.width > 0 {
+		// yuv420p needs an even width and height
+		if o.width%2 != 0 {
+			return info{}, fmt.Errorf("width must be even")
+		}
+		half := math.Round(float64(o.width*i.height) / float64(i.width) / 2)
+		i.height = 2 * int(math.Max(1, half))
+		i.width = o.width
+	}
+	return i, nil
+}
+
+// trim returns the input args which trim the video
+func (o options) trim() []string {
+	var args []string
+	if o.start > 0 {
+		args = append(args, "-ss", seconds(o.start))
+	}
+	if o.end > 0 {
+		args = append(args, "-to", seconds(o.end))
+	}
+	return args
+}
+
+// seconds formats the duration for ffmpeg
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// audio is where the audio of the converted
+// video comes from, by default it's the source
+type audio struct {
+	drop bool
+	path string // Replaces the source's audio if set
+}
+
+// args returns the ffmpeg args which add the audio as the
+// second input of the encoder and map it to the output, the
+// source's audio is trimmed the same way as the video
+func (a audio) args(src string, i info, trim []string) []string {
+	switch {
+	case a.drop:
+		return []string{"-an"}
+	case a.path != "":
+		return []string{"-i", a.path, "-map", "0:v", "-map", "1:a", "-shortest"}
+	case i.hasAudio:
+		args := append(trim[:len(trim):len(trim)], "-i", src)
+		return append(args, "-map", "0:v", "-map", "1:a", "-shortest")
+	default:
+		return nil
+	}
+}
+
+func Convert(ctx context.Context, src, dst string, o options) (err error) {
+	// Stop ffmpeg if the conversion fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The frames are piped at the output's frame
+	// rate so that they stay in sync with the audio
+	srcInfo, err := probe(ctx, src)
+	if err != nil {
+		return fmt.Errorf("probe: %w", err)
+	}
+	i, err := o.output(srcInfo)
+	if err != nil {
+		return err
+	}
 
 	// Handle the output of user progress
-	s, err := createSpinner()
+	p, err := newProgress(i.frames(), o.progressJSON)
 	if err != nil {
 		return err
 	}
 	defer func() {
-		s.Message("100%")
-		s.Stop()
+		p.stop(err)
 	}()
 
-	go func() {
-		err = s.Start()
-		if err != nil {
-			log.Fatalln(err)
-		}
-
-		d := <-ffDuration
-		for p := range ffProgress {
-			s.Message(fmt.Sprintf("%02.2f%%", (float32(p)/float32(d))*100))
-		}
-	}()
+	// The font is only parsed once for every frame
+	mem := &ascii.Memory{}
+	c, err := ascii.NewConverter(ascii.FontPts(o.fontsize), ascii.Interpolate(mem))
+	if err != nil {
+		return err
+	}
 
 	// Handle the decoding/encoding
-	mem := &ascii.Memory{}
-	for img := range imgD {
-		asciiImg, err := ascii.ConvertWithOpts(img,
-			ascii.FontPts(fontsize), ascii.Interpolate(mem))
-		if err != nil {
-			close(imgE)
-			return err
-		}
+	frames := newFramePool(i.width, i.height)
+	imgD, errD := decode(ctx, src, i, o.trim(), frames)
+	imgE, errE := encode(ctx, dst, i.rate, o.audio.args(src, i, o.trim()), o.args...)
 
-		imgE <- asciiImg
-		err = <-errE
-		if err != nil {
-			close(imgE)
+	err = pipeline(ctx, c, o.workers, imgD, func(img image.Image) error {
+		imgE <- img
+		if err := <-errE; err != nil {
 			return err
 		}
-	}
+		p.frame()
+		return nil
+	}, frames.put)
 	close(imgE)
+	if err != nil {
+		return err
+	}
 
-	select {
-	case err := <-errD:
-		if err != nil {
-			return fmt.Errorf("decode: %w", err)
-		}
-	case err := <-errE:
-		if err != nil {
-			return fmt.Errorf("encode: %w", err)
-		}
+	// Wait for the encoder to finish writing before
+	// returning since that cancels the context
+	if err := <-errD; err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	if err := <-errE; err != nil {
+		return fmt.Errorf("encode: %w", err)
 	}
 	return nil
 }
 
-func encode(ctx context.Context, path string, args ...string) (chan<- image.Image, <-chan error) {
-	// Make the channels
-	errC := make(chan error, 1)
-	imgC := make(chan image.Image)
+// framePool reuses the buffers of the decoded frames
+// once they've been converted
+type framePool struct {
+	rect image.Rectangle
+	pool sync.Pool
+}
 
-	// Process the extra args
-	var cmdArgs []string
-	cmdArgs = append(cmdArgs,
-		"-hide_banner", "-loglevel", "error",
-		"-f", "image2pipe", "-c:v", "png", "-i", "-",
-		"-y", "-an", "-pix_fmt", "yuv420p",
-	)
-	cmdArgs = append(cmdArgs, args...)
-	cmdArgs = append(cmdArgs, path)
-
-	// Filter out empty args
-	n := 0
-	for _, val := range cmdArgs {
-		if val != "" {
-			cmdArgs[n] = val
-			n++
-		}
+func newFramePool(width, height int) *framePool {
+	p := &framePool{rect: image.Rect(0, 0, width, height)}
+	p.pool.New = func() interface{} {
+		return image.NewRGBA(p.rect)
 	}
-	cmdArgs = cmdArgs[:n]
+	return p
+}
 
-	// Create the command
-	cmd := exec.CommandContext(ctx, "ffmpeg", cmdArgs...)
-	var e bytes.Buffer
-	cmd.Stderr = &e
+func (p *framePool) get() *image.RGBA {
+	return p.pool.Get().(*image.RGBA)
+}
+
+func (p *framePool) put(img image.Image) {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect == p.rect {
+		p.pool.Put(rgba)
+	}
+}
+
+func encode(ctx context.Context, path, rate string, audioArgs []string, args ...string) (chan<- image.Image, <-chan error) {
+	// Make the channels
+	errC := make(chan error, 1)
+	imgC := make(chan image.Image)
 
 	go func() {
 		defer close(errC)
 
+		// The size of the raw frames is only known once
+		// the first one has been converted
+		first, ok := <-imgC
+		if !ok {
+			errC <- fmt.Errorf("no frames to encode")
+			return
+		}
+		size := first.Bounds().Size()
+
+		// Process the extra args
+		var cmdArgs []string
+		cmdArgs = append(cmdArgs,
+			"-hide_banner", "-loglevel", "error",
+			"-f", "rawvideo", "-pix_fmt", "rgb24",
+			"-s", fmt.Sprintf("%dx%d", size.X, size.Y),
+			"-framerate", rate, "-i", "-",
+		)
+		cmdArgs = append(cmdArgs, audioArgs...)
+		cmdArgs = append(cmdArgs, "-y", "-pix_fmt", "yuv420p")
+		cmdArgs = append(cmdArgs, args...)
+		cmdArgs = append(cmdArgs, path)
+
+		// Filter out empty args
+		n := 0
+		for _, val := range cmdArgs {
+			if val != "" {
+				cmdArgs[n] = val
+				n++
+			}
+		}
+		cmdArgs = cmdArgs[:n]
+
+		// Create the command
+		cmd := exec.CommandContext(ctx, "ffmpeg", cmdArgs...)
+		var e bytes.Buffer
+		cmd.Stderr = &e
+
 		stdin, err := cmd.StdinPipe()
 		if err != nil {
 			errC <- fmtCmdErr(err, strings.TrimRight(e.String(), "\n"))
@@ -122,8 +254,11 @@ func encode(ctx context.Context, path string, args ...string) (chan<- image.Imag
 			return
 		}
 
-		for img := range imgC {
-			err = png.Encode(stdin, img)
+		// The converted frames are opaque so their alpha
+		// is dropped, the buffer is reused for every frame
+		buf := make([]byte, 3*size.X*size.Y)
+		for img := first; ok; img, ok = <-imgC {
+			err = writeRGB(stdin, img, size, buf)
 			if err != nil {
 				errC <- fmtCmdErr(err, strings.TrimRight(e.String(), "\n"))
 				return
@@ -143,78 +278,80 @@ func encode(ctx context.Context, path string, args ...string) (chan<- image.Imag
 	return imgC, errC
 }
 
-func decode(ctx context.Context, path string) (<-chan image.Image, <-chan time.Duration, <-chan time.Duration, <-chan error) {
+// writeRGB writes the image as a raw rgb24 frame of the given size
+func writeRGB(w io.Writer, img image.Image, size image.Point, buf []byte) error {
+	if img.Bounds().Size() != size {
+		return fmt.Errorf("frame is %v but the video is %v", img.Bounds().Size(), size)
+	}
+
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Rect, img, img.Bounds().Min, draw.Src)
+	}
+
+	i := 0
+	for y := 0; y < size.Y; y++ {
+		row := rgba.Pix[y*rgba.Stride : y*rgba.Stride+4*size.X]
+		for x := 0; x < len(row); x += 4 {
+			buf[i], buf[i+1], buf[i+2] = row[x], row[x+1], row[x+2]
+			i += 3
+		}
+	}
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// decode decodes the frames of the video at path, they're
+// trimmed, resampled and scaled on the way to match i
+func decode(ctx context.Context, path string, i info, trim []string, frames *framePool) (<-chan image.Image, <-chan error) {
 	// Make the channels
 	errC := make(chan error, 1)
 	imgC := make(chan image.Image)
-	timeDur := make(chan time.Duration, 1)
-	timeProg := make(chan time.Duration, 1)
 
 	// Create the command
-	cmd := exec.CommandContext(ctx,
-		"ffmpeg", "-i", path,
-		"-hide_banner", "-loglevel", "info",
-		"-vcodec", "png", "-f", "image2pipe", "-",
+	var cmdArgs []string
+	cmdArgs = append(cmdArgs, "-hide_banner", "-loglevel", "error")
+	cmdArgs = append(cmdArgs, trim...)
+	cmdArgs = append(cmdArgs,
+		"-i", path,
+		"-vf", fmt.Sprintf("fps=%s,scale=%d:%d", i.rate, i.width, i.height),
+		"-f", "rawvideo", "-pix_fmt", "rgba", "-",
 	)
+	cmd := exec.CommandContext(ctx, "ffmpeg", cmdArgs...)
+	var e bytes.Buffer
+	cmd.Stderr = &e
 
 	go func() {
 		defer close(errC)
 		defer close(imgC)
-		defer close(timeDur)
-		defer close(timeProg)
-
-		// Parse stderr for the duration of the track
-		stderr, err := cmd.StderrPipe()
-		if err != nil {
-			errC <- err
-			return
-		}
-		defer stderr.Close()
-
-		var lastErr string
-		go func() {
-			sc := parse.FFScanner(stderr)
-
-			for sc.Scan() {
-				if !strings.Contains(sc.Text(), "time=") {
-					lastErr = sc.Text()
-
-					t := parse.ScanDuration(sc.Text())
-					if t != 0 {
-						timeDur <- t
-					}
-					continue
-				}
-
-				t := parse.ScanTime(sc.Text())
-				if t != 0 {
-					timeProg <- t
-				}
-			}
-		}()
 
 		stdout, err := cmd.StdoutPipe()
 		if err != nil {
-			errC <- fmtCmdErr(err, lastErr)
+			errC <- fmtCmdErr(err, e.String())
 			return
 		}
 		defer stdout.Close()
 
 		err = cmd.Start()
 		if err != nil {
-			errC <- fmtCmdErr(err, lastErr)
+			errC <- fmtCmdErr(err, e.String())
 			return
 		}
 
+		// Each frame is read straight into a reused buffer
+		r := bufio.NewReaderSize(stdout, 1<<20)
 		for {
-			img, err := png.Decode(stdout)
+			img := frames.get()
+			_, err := io.ReadFull(r, img.Pix)
 			if err != nil {
 				// Treat EOFs as end of the pipe so just break
 				// and finish the command
 				if err == io.EOF || err == io.ErrUnexpectedEOF {
 					break
 				}
-				errC <- fmtCmdErr(err, lastErr)
+				errC <- fmtCmdErr(err, e.String())
 				return
 			}
 
@@ -223,29 +360,14 @@ func decode(ctx context.Context, path string) (<-chan image.Image, <-chan time.D
 
 		err = cmd.Wait()
 		if err != nil {
-			errC <- fmtCmdErr(err, lastErr)
+			errC <- fmtCmdErr(err, e.String())
 			return
 		}
 	}()
 
-	return imgC, timeDur, timeProg, errC
+	return imgC, errC
 }
 
 func fmtCmdErr(err error, s string) error {
 	return fmt.Errorf("%w: %s", err, strings.TrimRight(s, "\n"))
 }
-
-func createSpinner() (*yacspin.Spinner, error) {
-	cfg := yacspin.Config{
-		Frequency:       100 * time.Millisecond,
-		CharSet:         yacspin.CharSets[59],
-		Suffix:          " Processing",
-		SuffixAutoColon: true,
-		Message:         "0%",
-		StopMessage:     "Done",
-		StopCharacter:   "✓",
-		StopColors:      []string{"fgGreen"},
-	}
-
-	return yacspin.New(cfg)
-}
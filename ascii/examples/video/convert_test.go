@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutput(t *testing.T) {
+	src := info{rate: "30000/1001", width: 1280, height: 720, duration: time.Minute, hasAudio: true}
+
+	tests := []struct {
+		name string
+		o    options
+		want info
+	}{
+		{"Unchanged", options{}, src},
+		{"Trimmed", options{start: 10 * time.Second, end: 25 * time.Second},
+			info{rate: src.rate, width: 1280, height: 720, duration: 15 * time.Second, hasAudio: true}},
+		{"EndAfterVideo", options{start: 50 * time.Second, end: 2 * time.Minute},
+			info{rate: src.rate, width: 1280, height: 720, duration: 10 * time.Second, hasAudio: true}},
+		{"FPS", options{fps: 12.5},
+			info{rate: "12.5", width: 1280, height: 720, duration: time.Minute, hasAudio: true}},
+		{"Width", options{width: 640},
+			info{rate: src.rate, width: 640, height: 360, duration: time.Minute, hasAudio: true}},
+		{"OddHeight", options{width: 300},
+			info{rate: src.rate, width: 300, height: 168, duration: time.Minute, hasAudio: true}},
+		{"TinyWidth", options{width: 2},
+			info{rate: src.rate, width: 2, height: 2, duration: time.Minute, hasAudio: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.o.output(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if got.height%2 != 0 {
+				t.Errorf("height %d is odd", got.height)
+			}
+		})
+	}
+
+	invalid := map[string]options{
+		"OddWidth":         {width: 301},
+		"EndBeforeStart":   {start: 20 * time.Second, end: 10 * time.Second},
+		"StartAfterVideo":  {start: 2 * time.Minute},
+		"StartAtVideosEnd": {start: time.Minute},
+	}
+	for name, o := range invalid {
+		if _, err := o.output(src); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -19,7 +19,9 @@ func timeAsDuration(format, t string) (time.Duration, error) {
 	return parsed.Sub(originTime), nil
 }
 
-func duration(t string) (time.Duration, error) {
+// Duration parses a time in the video, either as HH:MM:SS,
+// MM:SS or a number of seconds such as 90 or 1.5
+func Duration(t string) (time.Duration, error) {
 	var err error
 	var parsed time.Duration
 
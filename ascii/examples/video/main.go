@@ -7,7 +7,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"runtime"
 	"strings"
+	"time"
+
+	"video/internal/parse"
 )
 
 func main() {
@@ -16,10 +20,20 @@ func main() {
 	stringArgs := flag.String("args", "", "specify extra args for ffmpeg")
 	fontsize := flag.Float64("fontsize", 14, "fontsize of the ascii characters")
 	overwrite := flag.Bool("y", false, "automatically overwrites the output file if it exists")
+	dropAudio := flag.Bool("an", false, "drop the audio instead of copying it from the input")
+	audioSrc := flag.String("audio", "", "path to audio which replaces the input's audio")
+	jsonProgress := flag.Bool("json", false, "write the progress to stdout as JSON lines instead of showing a spinner")
+	start := flag.String("ss", "", "time to start the video from, as HH:MM:SS, MM:SS or seconds")
+	end := flag.String("to", "", "time to end the video at, as HH:MM:SS, MM:SS or seconds")
+	fps := flag.Float64("fps", 0, "frame rate of the output, the input's is kept if 0")
+	width := flag.Int("width", 0, "even width to scale the input to before converting it, the input's is kept if 0")
+	play := flag.Bool("play", false, "play the video in the terminal instead of writing it to a file")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of frames to convert concurrently")
 
 	// Parse flags
 	flag.Usage = func() {
-		fmt.Printf("Usage: ./video -i in.mp4 out.mp4\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: ./video -i in.mp4 out.mp4\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       ./video -i in.mp4 -play\n")
 		flag.PrintDefaults()
 	}
 	flag.Parse()
@@ -27,35 +41,84 @@ func main() {
 	var output string
 	if flag.Parse(); len(flag.Args()) > 0 {
 		output = flag.Args()[0]
-	} else {
+	} else if !*play {
 		flag.Usage()
 		os.Exit(1)
 	}
 
 	if *src == "" {
-		fmt.Println("Input file not specified!")
+		fmt.Fprintln(os.Stderr, "Input file not specified!")
+		os.Exit(1)
+	}
+	if *fps < 0 || *width < 0 {
+		fmt.Fprintln(os.Stderr, "The frame rate and width cannot be negative!")
+		os.Exit(1)
+	}
+	if *workers < 1 {
+		fmt.Fprintln(os.Stderr, "There must be at least one worker!")
 		os.Exit(1)
 	}
+	if *dropAudio && *audioSrc != "" {
+		fmt.Fprintln(os.Stderr, "Audio cannot be both dropped and replaced!")
+		os.Exit(1)
+	}
+
+	// Check if overwrite, stdout is kept for the JSON
+	// progress so the prompt and errors go to stderr
+	if !*play && exists(output) && !*overwrite {
+		if *jsonProgress {
+			fmt.Fprintf(os.Stderr, "%s already exists, use -y to overwrite it\n", output)
+			os.Exit(1)
+		}
 
-	// Check if overwrite
-	if exists(*src) && !*overwrite {
 		scanner := bufio.NewScanner(os.Stdin)
-		fmt.Print("Would you like to overwrite the file? (y/N): ")
+		fmt.Fprint(os.Stderr, "Would you like to overwrite the file? (y/N): ")
 		scanner.Scan()
 		if strings.ToLower(strings.TrimSpace(scanner.Text())) != "y" {
-			fmt.Println("File already exists!")
+			fmt.Fprintln(os.Stderr, "File already exists!")
 			os.Exit(1)
 		}
 	}
 
 	// Perform the conversion
-	args := strings.Split(*stringArgs, " ")
-	err := Convert(context.Background(), *src, output, *fontsize, args...)
+	o := options{
+		fontsize: *fontsize,
+		workers:  *workers,
+		audio:    audio{drop: *dropAudio, path: *audioSrc},
+		fps:      *fps,
+		width:    *width,
+		args:     strings.Split(*stringArgs, " "),
+	}
+	if *jsonProgress {
+		o.progressJSON = os.Stdout
+	}
+	o.start = parseTime(*start)
+	o.end = parseTime(*end)
+
+	var err error
+	if *play {
+		err = Play(context.Background(), *src, o)
+	} else {
+		err = Convert(context.Background(), *src, output, o)
+	}
 	if err != nil {
 		log.Fatalln(err)
 	}
 }
 
+// parseTime parses the time of a trim flag, it's 0 if it isn't set
+func parseTime(t string) time.Duration {
+	if t == "" {
+		return 0
+	}
+	d, err := parse.Duration(t)
+	if err != nil || d < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid time: %s\n", t)
+		os.Exit(1)
+	}
+	return d
+}
+
 func exists(fp string) bool {
 	if _, err := os.Stat(fp); os.IsNotExist(err) {
 		return false
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"image"
+	"sync"
+
+	"github.com/fiwippi/go-ascii"
+)
+
+// frame is an image and its position in the video,
+// the image is converted in steps as it moves through
+// the pipeline
+type frame struct {
+	seq int
+	img image.Image // The source image until it's drawn
+	f   *ascii.Frame
+}
+
+// pipeline converts the images from in using a pool of workers
+// and calls out with the converted images in order. The images
+// are sampled and drawn concurrently, but they're mapped to their
+// characters in order since that uses the memory. Once an image
+// has been converted it's passed to release so it can be reused
+func pipeline(ctx context.Context, c *ascii.Converter, workers int, in <-chan image.Image, out func(image.Image) error, release func(image.Image)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The first error stops the pipeline
+	var once sync.Once
+	var pErr error
+	fail := func(err error) {
+		once.Do(func() {
+			pErr = err
+			cancel()
+		})
+	}
+
+	// Only a limited number of frames can be in the pipeline
+	// at once, which bounds the frames waiting to be reordered
+	tokens := make(chan struct{}, 2*workers)
+
+	toSample := make(chan frame)
+	go func() {
+		defer close(toSample)
+		defer func() {
+			// Let the decoder finish if the pipeline stopped
+			go func() {
+				for range in {
+				}
+			}()
+		}()
+
+		seq := 0
+		for img := range in {
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case toSample <- frame{seq: seq, img: img}:
+			case <-ctx.Done():
+				return
+			}
+			seq++
+		}
+	}()
+
+	// Sample the frames concurrently
+	sampled := workerPool(ctx, workers, toSample, func(fr frame) (frame, error) {
+		f, err := c.Sample(fr.img)
+		return frame{seq: fr.seq, img: fr.img, f: f}, err
+	}, fail)
+
+	// Map the frames in order
+	mapped := make(chan frame)
+	go func() {
+		defer close(mapped)
+		reorder(sampled, func(fr frame) bool {
+			if err := c.Map(fr.f); err != nil {
+				fail(err)
+				return false
+			}
+
+			select {
+			case mapped <- fr:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	// Draw the frames concurrently
+	drawn := workerPool(ctx, workers, mapped, func(fr frame) (frame, error) {
+		img, err := c.Draw(fr.f)
+		if err == nil {
+			release(fr.img)
+		}
+		return frame{seq: fr.seq, img: img}, err
+	}, fail)
+
+	// Output the frames in order
+	reorder(drawn, func(fr frame) bool {
+		if err := out(fr.img); err != nil {
+			fail(err)
+			return false
+		}
+		<-tokens
+		return true
+	})
+
+	// Wait for the stages to stop
+	for range drawn {
+	}
+
+	if pErr != nil {
+		return pErr
+	}
+	return ctx.Err()
+}
+
+// workerPool calls fn concurrently for each frame from in and
+// sends the results to the returned channel, which is closed
+// once every worker has stopped. Errors are passed to fail
+func workerPool(ctx context.Context, workers int, in <-chan frame, fn func(frame) (frame, error), fail func(error)) <-chan frame {
+	out := make(chan frame)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fr := range in {
+				fr, err := fn(fr)
+				if err != nil {
+					fail(err)
+					return
+				}
+
+				select {
+				case out <- fr:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// reorder calls fn with the frames from in in the order of
+// their sequence numbers, it stops if fn returns false
+func reorder(in <-chan frame, fn func(frame) bool) {
+	next := 0
+	pending := make(map[int]frame)
+	for fr := range in {
+		pending[fr.seq] = fr
+		for {
+			p, found := pending[next]
+			if !found {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			if !fn(p) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fiwippi/go-ascii"
+)
+
+// testFrames creates frames whose brightness changes
+// gradually so that interpolating them matters
+func testFrames(n int) []image.Image {
+	frames := make([]image.Image, n)
+	for i := range frames {
+		img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+		for y := 0; y < 48; y++ {
+			for x := 0; x < 64; x++ {
+				v := uint8((x*4 + y*2 + i*12) % 256)
+				img.Set(x, y, color.RGBA{v, 255 - v, uint8(i * 8), 255})
+			}
+		}
+		frames[i] = img
+	}
+	return frames
+}
+
+func send(frames []image.Image) <-chan image.Image {
+	in := make(chan image.Image)
+	go func() {
+		defer close(in)
+		for _, img := range frames {
+			in <- img
+		}
+	}()
+	return in
+}
+
+func newTestConverter(t *testing.T) *ascii.Converter {
+	mem := &ascii.Memory{SceneCut: -1}
+	c, err := ascii.NewConverter(ascii.FontPts(8), ascii.Interpolate(mem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPipeline(t *testing.T) {
+	frames := testFrames(24)
+
+	// The frames converted one at a time
+	serial := newTestConverter(t)
+	var want []*image.RGBA
+	for _, img := range frames {
+		asciiImg, err := serial.Convert(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, asciiImg.(*image.RGBA))
+	}
+
+	var mu sync.Mutex
+	released := 0
+	var got []*image.RGBA
+	c := newTestConverter(t)
+	err := pipeline(context.Background(), c, 4, send(frames), func(img image.Image) error {
+		got = append(got, img.(*image.RGBA))
+		return nil
+	}, func(image.Image) {
+		mu.Lock()
+		released++
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d frames, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(got[i].Pix, want[i].Pix) {
+			t.Errorf("frame %d differs from the serial conversion", i)
+		}
+	}
+	if released != len(frames) {
+		t.Errorf("released %d frames, want %d", released, len(frames))
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	errOut := errors.New("encoder failed")
+
+	n := 0
+	done := make(chan error, 1)
+	go func() {
+		c := newTestConverter(t)
+		done <- pipeline(context.Background(), c, 4, send(testFrames(24)), func(image.Image) error {
+			n++
+			if n == 3 {
+				return errOut
+			}
+			return nil
+		}, func(image.Image) {})
+	}()
+
+	// The pipeline stops instead of deadlocking
+	select {
+	case err := <-done:
+		if err != errOut {
+			t.Errorf("got error %v, want %v", err, errOut)
+		}
+		if n != 3 {
+			t.Errorf("out was called %d times after failing", n)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("pipeline didn't stop after an error")
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		c := newTestConverter(t)
+		n := 0
+		done <- pipeline(ctx, c, 4, send(testFrames(24)), func(image.Image) error {
+			if n++; n == 2 {
+				cancel()
+			}
+			return nil
+		}, func(image.Image) {})
+	}()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("pipeline didn't stop after being cancelled")
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fiwippi/go-ascii"
+)
+
+// How long the characters take to settle when the frames change
+const playSmoothing = 100 * time.Millisecond
+
+// Play plays the video in the terminal as ANSI text sized to fit
+// it. The frames are shown at their timestamps and are dropped
+// if converting them falls behind. Space pauses the video and
+// q quits it, the audio isn't played
+func Play(ctx context.Context, src string, o options) error {
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt)
+	defer cancel()
+
+	srcInfo, err := probe(ctx, src)
+	if err != nil {
+		return fmt.Errorf("probe: %w", err)
+	}
+
+	// The last row of the terminal shows the status
+	termCols, termRows, err := terminalSize()
+	if err != nil {
+		return fmt.Errorf("terminal: %w", err)
+	}
+	cols, rows := fitText(srcInfo.width, srcInfo.height, termCols, termRows-1)
+
+	// The frames only need a few pixels for each character
+	if o.width == 0 && srcInfo.width > 4*cols {
+		o.width = 4 * cols
+	}
+	i, err := o.output(srcInfo)
+	if err != nil {
+		return err
+	}
+
+	// Read the keys as soon as they're pressed
+	state, err := stty("-g")
+	if err != nil {
+		return fmt.Errorf("terminal: %w", err)
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return fmt.Errorf("terminal: %w", err)
+	}
+	defer stty(state)
+
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := os.Stdin.Read(buf); err != nil {
+				return
+			}
+			select {
+			case keys <- buf[0]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Hide the cursor and clear the screen while playing
+	w := bufio.NewWriter(os.Stdout)
+	w.WriteString("\x1b[?25l\x1b[2J")
+	defer func() {
+		w.WriteString("\x1b[0m\x1b[?25h\n")
+		w.Flush()
+	}()
+
+	frames := newFramePool(i.width, i.height)
+	imgD, errD := decode(ctx, src, i, o.trim(), frames)
+	defer func() {
+		// Stop ffmpeg and let the decoder finish
+		cancel()
+		for range imgD {
+		}
+	}()
+
+	p := player{
+		w:        w,
+		interval: time.Duration(float64(time.Second) / i.fps()),
+		duration: i.duration,
+		rows:     rows,
+	}
+	weight, err := ascii.TimeConstant(playSmoothing, p.interval)
+	if err != nil {
+		return err
+	}
+	mem := &ascii.Memory{Weight: weight}
+	mode := ansiMode()
+
+	start := time.Now()
+	for {
+		var img image.Image
+		select {
+		case <-ctx.Done():
+			return nil
+		case k := <-keys:
+			switch k {
+			case 'q', 'Q':
+				return nil
+			case ' ', 'p', 'P':
+				// The frames are due later by
+				// however long it was paused for
+				pausedAt := time.Now()
+				if !p.pause(ctx, keys) {
+					return nil
+				}
+				start = start.Add(time.Since(pausedAt))
+			}
+			continue
+		case decoded, ok := <-imgD:
+			if !ok {
+				if err := <-errD; err != nil {
+					return fmt.Errorf("decode: %w", err)
+				}
+				return nil
+			}
+			img = decoded
+		}
+
+		// Each frame is due at its timestamp, frames
+		// which are already late are dropped
+		due := start.Add(time.Duration(p.frames) * p.interval)
+		p.frames++
+		if time.Since(due) > p.interval {
+			p.dropped++
+			frames.put(img)
+			continue
+		}
+
+		text, err := ascii.ConvertANSI(img, cols, rows, mode, ascii.Interpolate(mem))
+		frames.put(img)
+		if err != nil {
+			return err
+		}
+
+		time.Sleep(time.Until(due))
+		w.WriteString("\x1b[H")
+		w.WriteString(text)
+		p.status("")
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// player tracks the playback of the video
+type player struct {
+	w        *bufio.Writer
+	interval time.Duration // Time between each frame
+	duration time.Duration // Length of the video, 0 if it isn't known
+	rows     int           // Rows of text in each frame
+
+	frames  int // Frames which have been shown or dropped
+	dropped int
+}
+
+// pause shows the video is paused until it's unpaused,
+// it returns false if the video is quit instead
+func (p *player) pause(ctx context.Context, keys <-chan byte) bool {
+	p.status("paused")
+	p.w.Flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case k := <-keys:
+			switch k {
+			case 'q', 'Q':
+				return false
+			case ' ', 'p', 'P':
+				return true
+			}
+		}
+	}
+}
+
+// status writes the status on the row below the frame
+func (p *player) status(msg string) {
+	pos := formatSeconds((time.Duration(p.frames) * p.interval).Seconds())
+	if p.duration > 0 {
+		pos += " / " + formatSeconds(p.duration.Seconds())
+	}
+	if msg == "" {
+		msg = "[space] pause  [q] quit"
+	}
+	fmt.Fprintf(p.w, "\x1b[%d;1H\x1b[0m\x1b[K%s  %d dropped  %s", p.rows+1, pos, p.dropped, msg)
+}
+
+// fitText returns the largest number of columns and rows
+// of text which fit in maxCols and maxRows and keep the
+// video's aspect ratio, given characters are about twice
+// as tall as they are wide
+func fitText(width, height, maxCols, maxRows int) (int, int) {
+	aspect := float64(height) / float64(width) / 2
+	cols := maxCols
+	rows := int(math.Round(float64(cols) * aspect))
+	if rows > maxRows {
+		rows = maxRows
+		cols = int(math.Round(float64(rows) / aspect))
+	}
+	return int(math.Max(1, float64(cols))), int(math.Max(1, float64(rows)))
+}
+
+// ansiMode returns the colour depth the terminal supports
+func ansiMode() ascii.ANSIMode {
+	switch os.Getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return ascii.ANSITrueColour
+	default:
+		return ascii.ANSI256
+	}
+}
+
+// terminalSize returns the number of columns and rows of the terminal
+func terminalSize() (int, int, error) {
+	out, err := stty("size")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("invalid size: %q", out)
+	}
+	rows, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	cols, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if cols < 1 || rows < 2 {
+		return 0, 0, fmt.Errorf("terminal is too small")
+	}
+	return cols, rows, nil
+}
+
+// stty runs stty on the terminal, which is
+// expected to be stdin, and returns its output
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// info describes the streams of a video
+type info struct {
+	rate          string // Frame rate as a fraction, e.g. 30000/1001
+	width, height int    // Size of the frames once they're rotated
+	duration      time.Duration
+	hasAudio      bool
+}
+
+// frames returns the number of frames in the video
+// if it's known, otherwise 0
+func (i info) frames() int {
+	return int(math.Round(i.duration.Seconds() * i.fps()))
+}
+
+// fps returns the frame rate in frames per second
+func (i info) fps() float64 {
+	parts := strings.SplitN(i.rate, "/", 2)
+	num, _ := strconv.ParseFloat(parts[0], 64)
+	if len(parts) == 1 {
+		return num
+	}
+	den, _ := strconv.ParseFloat(parts[1], 64)
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+func probe(ctx context.Context, path string) (info, error) {
+	cmd := exec.CommandContext(ctx,
+		"ffprobe", "-hide_banner", "-loglevel", "error",
+		"-print_format", "json", "-show_streams", "-show_format", path,
+	)
+	var e bytes.Buffer
+	cmd.Stderr = &e
+
+	out, err := cmd.Output()
+	if err != nil {
+		return info{}, fmtCmdErr(err, e.String())
+	}
+
+	var probed struct {
+		Streams []struct {
+			CodecType    string `json:"codec_type"`
+			Width        int    `json:"width"`
+			Height       int    `json:"height"`
+			AvgFrameRate string `json:"avg_frame_rate"`
+			RFrameRate   string `json:"r_frame_rate"`
+			Tags         struct {
+				Rotate string `json:"rotate"`
+			} `json:"tags"`
+			SideDataList []struct {
+				Rotation float64 `json:"rotation"`
+			} `json:"side_data_list"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out, &probed); err != nil {
+		return info{}, err
+	}
+
+	var i info
+	for _, s := range probed.Streams {
+		switch s.CodecType {
+		case "video":
+			if i.rate != "" {
+				continue
+			}
+
+			// The average frame rate is 0/0 if it's unknown
+			i.rate = s.AvgFrameRate
+			if i.fps() == 0 {
+				i.rate = s.RFrameRate
+			}
+
+			// ffmpeg rotates the frames when decoding them so
+			// the width and height swap if they're on their side
+			rotation, _ := strconv.ParseFloat(s.Tags.Rotate, 64)
+			for _, sd := range s.SideDataList {
+				if sd.Rotation != 0 {
+					rotation = sd.Rotation
+				}
+			}
+			i.width, i.height = s.Width, s.Height
+			if int(math.Abs(rotation))%180 == 90 {
+				i.width, i.height = i.height, i.width
+			}
+		case "audio":
+			i.hasAudio = true
+		}
+	}
+	if i.rate == "" || i.fps() == 0 {
+		return info{}, fmt.Errorf("%s has no video stream with a frame rate", path)
+	}
+	if i.width <= 0 || i.height <= 0 {
+		return info{}, fmt.Errorf("%s has no video stream with a frame size", path)
+	}
+
+	// The duration is left as 0 if it isn't known
+	seconds, _ := strconv.ParseFloat(probed.Format.Duration, 64)
+	i.duration = time.Duration(seconds * float64(time.Second))
+
+	return i, nil
+}
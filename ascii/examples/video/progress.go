@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/theckman/yacspin"
+)
+
+// How often the progress is reported
+const reportInterval = 100 * time.Millisecond
+
+// progress counts the frames which have been converted and
+// reports them either with a spinner or as JSON lines
+type progress struct {
+	total int // Number of frames expected, 0 if it isn't known
+	done  int
+	start time.Time
+	last  time.Time
+
+	spinner *yacspin.Spinner
+	enc     *json.Encoder
+}
+
+// event is a snapshot of the progress, it's what's written as JSON
+type event struct {
+	Frame   int     `json:"frame"`
+	Total   int     `json:"total"`
+	Percent float64 `json:"percent"`
+	FPS     float64 `json:"fps"`
+	Elapsed float64 `json:"elapsed"` // Seconds
+	ETA     float64 `json:"eta"`     // Seconds, 0 if it isn't known
+	Done    bool    `json:"done"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// newProgress starts reporting the progress of converting total
+// frames, if w isn't nil then it's written to it as JSON lines
+func newProgress(total int, w io.Writer) (*progress, error) {
+	now := time.Now()
+	p := &progress{total: total, start: now, last: now}
+	if w != nil {
+		p.enc = json.NewEncoder(w)
+		return p, p.enc.Encode(p.event(now))
+	}
+
+	s, err := createSpinner()
+	if err != nil {
+		return nil, err
+	}
+	p.spinner = s
+	return p, s.Start()
+}
+
+// frame marks another frame as converted
+func (p *progress) frame() {
+	p.done++
+
+	now := time.Now()
+	if now.Sub(p.last) < reportInterval {
+		return
+	}
+	p.last = now
+
+	e := p.event(now)
+	if p.enc != nil {
+		p.enc.Encode(e)
+		return
+	}
+	p.spinner.Message(e.String())
+}
+
+// stop stops reporting the progress, err is
+// the error the conversion failed with if any
+func (p *progress) stop(err error) {
+	e := p.event(time.Now())
+	e.Done = true
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	if p.enc != nil {
+		p.enc.Encode(e)
+		return
+	}
+	p.spinner.Message(e.String())
+	if err != nil {
+		p.spinner.StopFail()
+		return
+	}
+	p.spinner.Stop()
+}
+
+func (p *progress) event(now time.Time) event {
+	elapsed := now.Sub(p.start).Seconds()
+	e := event{Frame: p.done, Total: p.total, Elapsed: elapsed}
+	if elapsed > 0 {
+		e.FPS = float64(p.done) / elapsed
+	}
+	if p.total > 0 {
+		// The total is estimated from the duration
+		// so a few more frames than it can arrive
+		if e.Total < e.Frame {
+			e.Total = e.Frame
+		}
+		e.Percent = 100 * float64(e.Frame) / float64(e.Total)
+		if e.FPS > 0 {
+			e.ETA = float64(e.Total-e.Frame) / e.FPS
+		}
+	}
+	return e
+}
+
+// String formats the event for the spinner
+func (e event) String() string {
+	elapsed := formatSeconds(e.Elapsed)
+	if e.Total == 0 {
+		return fmt.Sprintf("%d frames, %.1f fps, %s elapsed", e.Frame, e.FPS, elapsed)
+	}
+
+	eta := "--:--"
+	if e.ETA > 0 || e.Frame >= e.Total {
+		eta = formatSeconds(e.ETA)
+	}
+	return fmt.Sprintf("%.2f%% (%d/%d frames), %.1f fps, %s elapsed, %s left",
+		e.Percent, e.Frame, e.Total, e.FPS, elapsed, eta)
+}
+
+// formatSeconds formats seconds as MM:SS or HH:MM:SS
+func formatSeconds(s float64) string {
+	t := int(s + 0.5)
+	if t >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", t/3600, t/60%60, t%60)
+	}
+	return fmt.Sprintf("%02d:%02d", t/60, t%60)
+}
+
+func createSpinner() (*yacspin.Spinner, error) {
+	cfg := yacspin.Config{
+		Frequency:         100 * time.Millisecond,
+		CharSet:           yacspin.CharSets[59],
+		Suffix:            " Processing",
+		SuffixAutoColon:   true,
+		Message:           "0%",
+		StopMessage:       "Done",
+		StopCharacter:     "✓",
+		StopColors:        []string{"fgGreen"},
+		StopFailMessage:   "Failed",
+		StopFailCharacter: "✗",
+		StopFailColors:    []string{"fgRed"},
+	}
+
+	return yacspin.New(cfg)
+}
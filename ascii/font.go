@@ -3,12 +3,15 @@ package ascii
 import (
 	_ "embed"
 	"errors"
+	"fmt"
 	"golang.org/x/image/math/fixed"
 	"image"
 	"image/color"
+	"image/draw"
 
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
 )
 
 //go:embed CascadiaMono-Bold.ttf
@@ -27,39 +30,160 @@ func init() {
 type parsedFont struct {
 	face          font.Face
 	height, width int
+
+	// The fallback fonts are used for the runes which
+	// the font has no glyph for, fonts[0] is the font
+	// itself and faces[0] is face
+	pts   float64
+	fonts []*opentype.Font
+	faces []font.Face
+}
+
+// parseFont creates a face of the font at the given size,
+// the font must have a glyph for '█' and each of the runes
+func parseFont(f *opentype.Font, pts float64, rs ...rune) (parsedFont, error) {
+	return parseFonts(f, nil, pts, rs...)
 }
 
-func parseFont(f *opentype.Font, pts float64) (parsedFont, error) {
-	// Create the font face
+// parseFonts creates a face of the font and its fallbacks
+// at the given size. The font must have a glyph for '█' and
+// either it or one of its fallbacks must have a glyph for
+// each of the runes. The size of the cells is always decided
+// by the font and not its fallbacks
+func parseFonts(f *opentype.Font, fallbacks []*opentype.Font, pts float64, rs ...rune) (parsedFont, error) {
+	// Create the font faces
+	pf := parsedFont{pts: pts}
+	for _, ft := range append([]*opentype.Font{f}, fallbacks...) {
+		face, err := newFace(ft, pts)
+		if err != nil {
+			return parsedFont{}, err
+		}
+		pf.fonts = append(pf.fonts, ft)
+		pf.faces = append(pf.faces, face)
+	}
+	pf.face = pf.faces[0]
+
+	// Ensure the runes can be drawn
+	var buf sfnt.Buffer
+	for _, r := range rs {
+		if pf.fontFor(&buf, r) < 0 {
+			if len(fallbacks) > 0 {
+				return parsedFont{}, fmt.Errorf("neither the font nor its fallbacks have a glyph for %q (%U)", r, r)
+			}
+			return parsedFont{}, fmt.Errorf("font has no glyph for %q (%U)", r, r)
+		}
+	}
+
+	// Process font face metrics
+	glyphBounds, _, found := pf.face.GlyphBounds(fullBlock)
+	if !found {
+		return parsedFont{}, errors.New("failed getting font face width")
+	}
+	pf.height = pf.face.Metrics().Ascent.Round()
+	pf.width = glyphBounds.Max.X.Round()
+	if pf.width < 1 || pf.height < 1 {
+		return parsedFont{}, fmt.Errorf("font size %v is too small, its cells are %dx%d pixels", pts, pf.width, pf.height)
+	}
+
+	return pf, nil
+}
+
+func newFace(f *opentype.Font, pts float64) (font.Face, error) {
 	faceOpts := &opentype.FaceOptions{
 		Size:    pts,
 		DPI:     72,
 		Hinting: font.HintingNone,
 	}
-	face, err := opentype.NewFace(f, faceOpts)
-	if err != nil {
-		return parsedFont{}, err
+	return opentype.NewFace(f, faceOpts)
+}
+
+// fontFor returns the index of the first font which has a
+// glyph for the rune, or -1 if none of them do
+func (pf parsedFont) fontFor(buf *sfnt.Buffer, r rune) int {
+	for i, f := range pf.fonts {
+		if gi, err := f.GlyphIndex(buf, r); err == nil && gi != 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// glyph is a rasterised rune, its bounds are relative
+// to a dot placed at the origin
+type glyph struct {
+	dr   image.Rectangle
+	mask *image.Alpha
+}
+
+// glyph rasterises the rune, if the face cannot render the
+// rune then the glyph's mask is nil. Runes which only the
+// fallbacks have are shrunk if they are wider than the cell
+// and centred in it, so that the cells are always the width
+// of the font's characters
+func (pf parsedFont) glyph(r rune) glyph {
+	var buf sfnt.Buffer
+	i := pf.fontFor(&buf, r)
+	if i <= 0 {
+		return rasterise(pf.face, r)
 	}
 
-	// Process font face metrics
-	glyphBounds, _, found := face.GlyphBounds('█')
-	if !found {
-		return parsedFont{}, errors.New("failed getting font face width")
+	face := pf.faces[i]
+	adv, ok := face.GlyphAdvance(r)
+	if !ok {
+		return glyph{}
+	}
+	if w := adv.Round(); w > pf.width {
+		scaled, err := newFace(pf.fonts[i], pf.pts*float64(pf.width)/float64(w))
+		if err != nil {
+			return glyph{}
+		}
+		face = scaled
+		adv, _ = face.GlyphAdvance(r)
 	}
 
-	return parsedFont{
-		face:   face,
-		height: face.Metrics().Ascent.Round(),
-		width:  glyphBounds.Max.X.Round(),
-	}, nil
+	g := rasterise(face, r)
+	g.dr = g.dr.Add(image.Pt((pf.width-adv.Round())/2, 0))
+	return g
 }
 
-func (pf parsedFont) drawString(s string, clr color.Color, dst *image.RGBA, x, y int) {
-	d := &font.Drawer{
-		Dst:  dst,
-		Src:  image.NewUniform(clr),
-		Face: pf.face,
-		Dot:  fixed.P(x, y),
+// rasterise draws the rune using the face, if the face
+// cannot render the rune then the glyph's mask is nil
+func rasterise(face font.Face, r rune) glyph {
+	dr, mask, maskp, _, ok := face.Glyph(fixed.Point26_6{}, r)
+	if !ok {
+		return glyph{}
 	}
-	d.DrawString(s)
+
+	// The face reuses the mask between calls so
+	// it has to be copied for it to be cached
+	a := image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+	draw.Draw(a, a.Bounds(), mask, maskp, draw.Src)
+
+	return glyph{dr: dr, mask: a}
+}
+
+// coverage returns the sum of the glyph's mask in the
+// range 0-1 for each pixel it covers
+func (g glyph) coverage() float64 {
+	if g.mask == nil {
+		return 0
+	}
+
+	var sum float64
+	for _, a := range g.mask.Pix {
+		sum += float64(a) / 255
+	}
+	return sum
+}
+
+// draw composites the glyph onto dst with its dot at the
+// given point, nothing outside of clip is drawn
+func (g glyph) draw(dst *image.RGBA, clr color.Color, dot image.Point, clip image.Rectangle) {
+	if g.mask == nil {
+		return
+	}
+
+	dr := g.dr.Add(dot)
+	r := dr.Intersect(clip)
+	draw.DrawMask(dst, r, image.NewUniform(clr), image.Point{}, g.mask, r.Min.Sub(dr.Min), draw.Over)
 }
@@ -0,0 +1,84 @@
+package ascii
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/opentype"
+)
+
+func TestFallbacks(t *testing.T) {
+	// Go Mono has no braille so the braille
+	// charset needs a fallback font
+	f, err := opentype.Parse(gomono.TTF)
+	require.Nil(t, err)
+	cset := CSet(" ⠁⠃⠇⠏⠟⠿⣿")
+
+	_, err = ConvertWithOpts(testImg, Font(f), cset)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "U+2801")
+
+	ascii, err := ConvertWithOpts(testImg, Font(f), cset, Fallbacks(defaultFont), Grid(40, 0))
+	require.Nil(t, err)
+
+	// The cells are the width of the font's characters
+	pf, err := parseFonts(f, []*opentype.Font{defaultFont}, 14)
+	require.Nil(t, err)
+	assert.Equal(t, 40*pf.width, ascii.Bounds().Dx())
+
+	t.Run("Shrunk", func(t *testing.T) {
+		// Glyphs from the fallbacks which are wider
+		// than the cell are shrunk and centred in it
+		wide, err := newFace(defaultFont, 40)
+		require.Nil(t, err)
+		pf.faces[1] = wide
+
+		g := pf.glyph('⣿')
+		require.NotNil(t, g.mask)
+		assert.GreaterOrEqual(t, g.dr.Min.X, 0)
+		assert.LessOrEqual(t, g.dr.Max.X, pf.width)
+	})
+
+	t.Run("Calibrate", func(t *testing.T) {
+		_, err := ConvertWithOpts(testImg, Font(f), cset, Fallbacks(defaultFont), Calibrate(0))
+		assert.Nil(t, err)
+
+		text, err := ConvertText(testImg, 20, 10, Font(f), cset, Fallbacks(defaultFont), MatchShapes(10))
+		require.Nil(t, err)
+		assert.Len(t, text, 10)
+	})
+
+	t.Run("SVG", func(t *testing.T) {
+		s, err := ConvertSVG(testImg, Font(f), cset, Fallbacks(defaultFont), Grid(20, 0))
+		require.Nil(t, err)
+		assert.Contains(t, s, "@font-face")
+		assert.Contains(t, s, `font-family:"Go Mono","go-ascii",monospace`)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertWithOpts(testImg, Fallbacks(nil))
+		assert.NotNil(t, err)
+
+		// Every rune of the charset is checked
+		_, err = ConvertWithOpts(testImg, CSet(" .:漢"))
+		require.NotNil(t, err)
+		assert.True(t, strings.Contains(err.Error(), "漢"))
+
+		_, err = ConvertWithOpts(testImg, Font(f), cset, Fallbacks(f))
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "fallbacks")
+
+		// Empty charsets and cells smaller than a pixel
+		// are rejected instead of panicking
+		_, err = ConvertWithOpts(testImg, CSet(""))
+		assert.NotNil(t, err)
+		_, err = ConvertText(testImg, 2, 2, CSet(""))
+		assert.NotNil(t, err)
+		_, err = ConvertWithOpts(testImg, FontPts(0.01))
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "too small")
+	})
+}
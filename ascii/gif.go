@@ -0,0 +1,171 @@
+package ascii
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"sort"
+)
+
+// gifColours is the number of glyph colours in the palette
+// of each frame, each colour also has a blend of it with the
+// background for the anti-aliased edges of the glyphs
+const gifColours = 127
+
+// ConvertGIF renders each frame of the given GIF using ascii
+// characters. The frames are composited according to their
+// disposal methods before they're converted so each frame of
+// the new GIF is complete, and it keeps the original delays
+// and loop count.
+//
+// The frames are interpolated using the Memory supplied with
+// Interpolate, if no Memory is supplied a new one is used
+func ConvertGIF(g *gif.GIF, opts ...Option) (*gif.GIF, error) {
+	if g == nil || len(g.Image) == 0 {
+		return nil, fmt.Errorf("gif cannot be nil or empty")
+	}
+	if len(g.Delay) != len(g.Image) {
+		return nil, fmt.Errorf("gif must have a delay for each frame")
+	}
+
+	// Create the options
+	o, err := newOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if o.mem == nil {
+		o.mem = &Memory{}
+	}
+
+	c, err := newConverter(o)
+	if err != nil {
+		return nil, err
+	}
+
+	// The GIF's frames can be smaller than the GIF
+	// itself so they are drawn onto a canvas
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+		for _, frame := range g.Image[1:] {
+			bounds = bounds.Union(frame.Bounds())
+		}
+	}
+	canvas := image.NewRGBA(bounds)
+
+	// Every frame is sampled and then drawn
+	p := c.newProgress(context.Background(), bounds, 2*len(g.Image))
+
+	out := &gif.GIF{
+		Delay:     append([]int(nil), g.Delay...),
+		LoopCount: g.LoopCount,
+	}
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		if disposal(g, i) == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		rendered, cs, err := c.draw(canvas, p)
+		if err != nil {
+			return nil, err
+		}
+
+		paletted := image.NewPaletted(rendered.Bounds(), gifPalette(cs, o))
+		draw.Draw(paletted, paletted.Bounds(), rendered, rendered.Bounds().Min, draw.Src)
+		out.Image = append(out.Image, paletted)
+		out.Disposal = append(out.Disposal, gif.DisposalNone)
+
+		switch disposal(g, i) {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	out.Config = image.Config{
+		ColorModel: out.Image[0].Palette,
+		Width:      out.Image[0].Bounds().Dx(),
+		Height:     out.Image[0].Bounds().Dy(),
+	}
+
+	return out, nil
+}
+
+func disposal(g *gif.GIF, i int) byte {
+	if i < len(g.Disposal) {
+		return g.Disposal[i]
+	}
+	return 0
+}
+
+// gifPalette creates a palette for a frame from the colours
+// its cells are drawn in. The most common colours are chosen,
+// along with blends of them and the background, since the
+// default quantiser would waste most of its colours on the
+// background and the anti-aliasing of the glyphs
+func gifPalette(cs []cell, opts *options) color.Palette {
+	type bin struct {
+		key        uint32
+		n          int
+		r, g, b, a uint64
+	}
+
+	// Group similar colours by using 5 bits for each channel
+	bins := make(map[uint32]*bin)
+	add := func(clr color.Color) {
+		r, g, b, a := clr.RGBA()
+		key := (r>>11)<<15 | (g>>11)<<10 | (b>>11)<<5 | a>>11
+		if bins[key] == nil {
+			bins[key] = &bin{key: key}
+		}
+		bn := bins[key]
+		bn.n++
+		bn.r, bn.g, bn.b, bn.a = bn.r+uint64(r), bn.g+uint64(g), bn.b+uint64(b), bn.a+uint64(a)
+	}
+	for _, c := range cs {
+		add(c.clr)
+		if c.bg != nil {
+			add(c.bg)
+		}
+	}
+
+	sorted := make([]*bin, 0, len(bins))
+	for _, bn := range bins {
+		sorted = append(sorted, bn)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].n != sorted[j].n {
+			return sorted[i].n > sorted[j].n
+		}
+		return sorted[i].key < sorted[j].key
+	})
+	if len(sorted) > gifColours {
+		sorted = sorted[:gifColours]
+	}
+
+	bg := color.RGBA64Model.Convert(opts.colours.background()).(color.RGBA64)
+	p := color.Palette{bg}
+	if opts.transparent {
+		p = append(p, color.Transparent)
+	}
+	for _, bn := range sorted {
+		n := uint64(bn.n)
+		clr := color.RGBA64{R: uint16(bn.r / n), G: uint16(bn.g / n), B: uint16(bn.b / n), A: uint16(bn.a / n)}
+		half := color.RGBA64{
+			R: uint16((uint32(clr.R) + uint32(bg.R)) / 2),
+			G: uint16((uint32(clr.G) + uint32(bg.G)) / 2),
+			B: uint16((uint32(clr.B) + uint32(bg.B)) / 2),
+			A: uint16((uint32(clr.A) + uint32(bg.A)) / 2),
+		}
+		p = append(p, clr, half)
+	}
+
+	return p
+}
@@ -0,0 +1,88 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testGIF creates a GIF of a white square moving across a
+// black background, every frame after the first only covers
+// the area which changed
+func testGIF() *gif.GIF {
+	g := &gif.GIF{LoopCount: 3}
+	for i := 0; i < 5; i++ {
+		r := image.Rect(0, 0, 200, 100)
+		if i > 0 {
+			r = image.Rect(i*30-30, 20, i*30+60, 80)
+		}
+
+		frame := image.NewPaletted(r, palette.Plan9)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				frame.Set(x, y, color.Black)
+				if x >= i*30 && x < i*30+60 && y >= 20 && y < 80 {
+					frame.Set(x, y, color.White)
+				}
+			}
+		}
+
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10+i)
+		g.Disposal = append(g.Disposal, gif.DisposalNone)
+	}
+	g.Config = image.Config{Width: 200, Height: 100}
+	return g
+}
+
+func TestConvertGIF(t *testing.T) {
+	g := testGIF()
+	out, err := ConvertGIF(g, FontPts(8))
+	require.Nil(t, err)
+
+	require.Len(t, out.Image, len(g.Image))
+	assert.Equal(t, g.Delay, out.Delay)
+	assert.Equal(t, g.LoopCount, out.LoopCount)
+	for _, frame := range out.Image {
+		assert.Equal(t, image.Rect(0, 0, 200, 100), frame.Bounds())
+		assert.LessOrEqual(t, len(frame.Palette), 256)
+	}
+
+	f, err := os.Create("test/convert-gif.gif")
+	require.Nil(t, err)
+	defer f.Close()
+	assert.Nil(t, gif.EncodeAll(f, out))
+
+	t.Run("Disposal", func(t *testing.T) {
+		// If the frames are disposed to the background then the
+		// area outside of the changed region becomes transparent
+		g := testGIF()
+		for i := range g.Disposal {
+			g.Disposal[i] = gif.DisposalBackground
+		}
+
+		out, err := ConvertGIF(g, FontPts(8), TransparentBackground())
+		require.Nil(t, err)
+		_, _, _, a := out.Image[2].At(190, 90).RGBA()
+		assert.Equal(t, uint32(0), a)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertGIF(nil)
+		assert.NotNil(t, err)
+
+		_, err = ConvertGIF(&gif.GIF{})
+		assert.NotNil(t, err)
+
+		g := testGIF()
+		g.Delay = g.Delay[1:]
+		_, err = ConvertGIF(g)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,307 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+)
+
+// cell is a single character of the converted output,
+// if bg is nil then the cell has no background colour.
+// If filled is set then the cell is outside of the mask
+// and is filled instead of being drawn with a rune
+type cell struct {
+	r      rune
+	clr    color.Color
+	bg     color.Color
+	bright float64
+	filled bool
+}
+
+// grid describes how the source image is divided
+// into character cells. Each cell covers cellW by
+// cellH pixels of the source image
+type grid struct {
+	bounds       image.Rectangle
+	cols, rows   int
+	cellW, cellH float64
+}
+
+// fixedGrid divides the bounds into cells which are w by h
+// pixels, the last row and column may be partially filled
+func fixedGrid(bounds image.Rectangle, w, h int) grid {
+	return grid{
+		bounds: bounds,
+		cols:   (bounds.Dx() + w - 1) / w,
+		rows:   (bounds.Dy() + h - 1) / h,
+		cellW:  float64(w),
+		cellH:  float64(h),
+	}
+}
+
+// fittedGrid divides the bounds into exactly cols by rows cells
+func fittedGrid(bounds image.Rectangle, cols, rows int) grid {
+	return grid{
+		bounds: bounds,
+		cols:   cols,
+		rows:   rows,
+		cellW:  float64(bounds.Dx()) / float64(cols),
+		cellH:  float64(bounds.Dy()) / float64(rows),
+	}
+}
+
+// origin returns the pixel at the top-left of the cell
+func (g grid) origin(col, row int) image.Point {
+	return image.Point{
+		X: g.bounds.Min.X + int(math.Floor(float64(col)*g.cellW)),
+		Y: g.bounds.Min.Y + int(math.Floor(float64(row)*g.cellH)),
+	}
+}
+
+// rect returns the pixels which the cell covers, every
+// cell covers at least its origin
+func (g grid) rect(col, row int) image.Rectangle {
+	min, max := g.origin(col, row), g.origin(col+1, row+1)
+	if max.X <= min.X {
+		max.X = min.X + 1
+	}
+	if max.Y <= min.Y {
+		max.Y = min.Y + 1
+	}
+	return image.Rectangle{Min: min, Max: max}.Intersect(g.bounds)
+}
+
+// samples are the cells of an image which have been sampled
+// but not yet mapped to runes. Sampling is the expensive part
+// of working out the cells and it doesn't use the memory, so
+// many images can be sampled concurrently
+type samples struct {
+	g  grid
+	cs []cell
+
+	// The shapes of the cells if they're matched
+	// and the brightness of each braille dot
+	shs  []shape
+	dots []float64
+}
+
+// cells maps every cell of the grid to the rune and colour
+// which represent it, the cells are returned in row-major
+// order. If shapes is not nil then cells with enough contrast
+// use the rune which matches their shape the closest.
+//
+// Each row is marked as processed once it's sampled, if the
+// progress' context is done then its error is returned
+func cells(img image.Image, g grid, opts *options, shapes *shapes, p *progress) ([]cell, error) {
+	s, err := sample(img, g, opts, shapes != nil, p)
+	if err != nil {
+		return nil, err
+	}
+	return s.cells(opts, shapes), nil
+}
+
+// sample samples every cell of the grid, if the
+// progress' context is done then its error is returned
+func sample(img image.Image, g grid, opts *options, withShapes bool, p *progress) (*samples, error) {
+	var s *samples
+	switch opts.render {
+	case RenderBraille:
+		s = sampleBraille(img, g, opts, p)
+	case RenderHalfBlock:
+		s = sampleHalfBlocks(img, g, opts, p)
+	default:
+		s = sampleCharset(img, g, opts, withShapes, p)
+	}
+	if err := p.failed(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// cells maps the samples to the runes and colours of the
+// cells, this uses the memory so if it's shared then the
+// samples of each image must be mapped in order. The cells
+// are mapped in place so samples can only be mapped once
+func (s *samples) cells(opts *options, shapes *shapes) []cell {
+	switch opts.render {
+	case RenderBraille:
+		s.braille(opts)
+	case RenderHalfBlock:
+	default:
+		s.charset(opts, shapes)
+	}
+
+	if opts.mask != nil {
+		applyMask(s.cs, s.g, opts)
+	}
+	opts.colours.apply(s.cs)
+	return s.cs
+}
+
+// sampleCharset samples the colour and brightness of every
+// cell of the grid, nil is returned if the conversion is
+// stopped
+func sampleCharset(img image.Image, g grid, opts *options, withShapes bool, p *progress) *samples {
+	// Sample the colour of each cell, this is the
+	// expensive part so bands of rows are sampled
+	// concurrently
+	cs := make([]cell, g.cols*g.rows)
+	var shs []shape
+	if withShapes {
+		shs = make([]shape, len(cs))
+	}
+	bands(opts.workers, g.rows, func(start, end int) {
+		s := newSampler(img, opts.sampling)
+		for row := start; row < end; row++ {
+			for col := 0; col < g.cols; col++ {
+				i := row*g.cols + col
+				cs[i].clr, cs[i].bright = s.sample(g.rect(col, row))
+				if shs != nil {
+					shs[i] = s.shape(g.rect(col, row))
+				}
+			}
+			if p.row() != nil {
+				return
+			}
+		}
+	})
+	if p.failed() != nil {
+		return nil
+	}
+
+	return &samples{g: g, cs: cs, shs: shs}
+}
+
+// charset maps every cell to the rune from the
+// charset which matches its brightness
+func (s *samples) charset(opts *options, shapes *shapes) {
+	cs, shs := s.cs, s.shs
+
+	// Convert the charset to its runes, the conversion to
+	// runes is done so that unicode characters can be indexed
+	// appropriately instead of individual code points
+	rs := []rune(opts.charset)
+
+	// The memory may be shared between
+	// conversions on different goroutines
+	if opts.mem != nil {
+		opts.mem.mu.Lock()
+		defer opts.mem.mu.Unlock()
+		opts.mem.frame(s.g.cols, s.g.rows, func(i int) float64 {
+			return cs[i].bright
+		})
+	}
+
+	adjust := opts.tone.curve(len(cs), func(i int) float64 {
+		return cs[i].bright
+	}, opts.mem)
+
+	// Adjust the brightnesses serially so that the
+	// memory is always updated in the same order
+	for i := range cs {
+		if adjust != nil {
+			cs[i].bright = adjust(cs[i].bright)
+		}
+
+		// Interpolate if memory is specified
+		if opts.mem != nil {
+			cs[i].bright = opts.mem.interpolate(cs[i].bright, i)
+		}
+	}
+
+	// Map the brightnesses to the runes, dithering
+	// needs every brightness at once to spread the
+	// error between them
+	var indices []int
+	if opts.dither != nil {
+		bs := make([]float64, len(cs))
+		for i := range cs {
+			bs[i] = cs[i].bright
+		}
+		indices = opts.dither.indices(bs, s.g.cols, s.g.rows, len(rs))
+	}
+
+	for i := range cs {
+		var index int
+		if indices != nil {
+			index = indices[i]
+		} else {
+			index = charIndex(cs[i].bright, len(rs))
+		}
+
+		// Inverted colours have a light background
+		// so the charset is used in reverse
+		if opts.colours.inverted() {
+			index = len(rs) - 1 - index
+			if shs != nil {
+				shs[i].negate()
+			}
+		}
+
+		cs[i].r = rs[index]
+		if shs != nil && shapes != nil {
+			if r, ok := shapes.match(shs[i]); ok {
+				cs[i].r = r
+			}
+		}
+	}
+}
+
+// bands splits n rows into contiguous bands and calls fn
+// for each band on its own goroutine, at most workers
+// bands are created. bands returns once every call of
+// fn has returned
+func bands(workers, n int, fn func(start, end int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		fn(0, n)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(i*n/workers, (i+1)*n/workers)
+	}
+	wg.Wait()
+}
+
+// brightness returns the perceived brightness of the
+// colour in the range 0-255
+func brightness(clr color.Color) float64 {
+	// Scale the values from 0-65535 to 0-255
+	r, g, b, _ := clr.RGBA()
+	r, g, b = r>>8, g>>8, b>>8
+
+	// Get a brightness value of the colour from
+	// here: https://www.w3.org/TR/AERT/#color-contrast
+	//
+	// This method isn't super-accurate since the
+	// standards used are dated but this is negligible
+	// in terms of the final image produced
+	return 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+}
+
+// charIndex converts a brightness in the range 0-255 to the
+// index of the rune in a charset of length n
+func charIndex(bright float64, n int) int {
+	// Scale brightness in range 0-1
+	bright /= 255
+	if bright > 1.0 {
+		bright = 1.0
+	}
+
+	// Use that as a percentage of the charset's length
+	// to get the index of the respective rune
+	index := int(bright * float64(n-1))
+	if index > n-1 {
+		index = n - 1
+	}
+	return index
+}
@@ -0,0 +1,96 @@
+package ascii
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// ConvertHTML renders the given image as a HTML <pre> element
+// whose characters are coloured using <span> elements, runs of
+// characters of the same colour share a <span>.
+//
+// The characters are the same as those which ConvertWithOpts
+// draws when given the same Option(s)
+func ConvertHTML(img image.Image, opts ...Option) (string, error) {
+	c, err := NewConverter(opts...)
+	if err != nil {
+		return "", err
+	}
+	return c.ConvertHTML(img)
+}
+
+// ConvertHTML renders the given image as a HTML <pre> element,
+// see ConvertHTML for details
+func (c *Converter) ConvertHTML(img image.Image) (string, error) {
+	// Ensure image exists
+	if img == nil {
+		return "", fmt.Errorf("image cannot be nil")
+	}
+	_, g, cs, err := c.cells(img, c.newProgress(context.Background(), img.Bounds(), 1))
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<pre style="`)
+	if !c.opts.transparent {
+		fmt.Fprintf(&sb, "background-color:%s;", cssColour(c.opts.colours.background()))
+	}
+	fmt.Fprintf(&sb, `font-family:monospace;font-size:%gpx;line-height:%dpx">`, c.opts.fontPts, c.cellH)
+
+	for row := 0; row < g.rows; row++ {
+		runs(cs[row*g.cols:(row+1)*g.cols], func(start int, run []cell) {
+			fmt.Fprintf(&sb, `<span style="color:%s`, cssColour(run[0].clr))
+			if run[0].bg != nil {
+				fmt.Fprintf(&sb, ";background-color:%s", cssColour(run[0].bg))
+			}
+			sb.WriteString(`">`)
+			for _, cl := range run {
+				sb.WriteString(html.EscapeString(string(cl.r)))
+			}
+			sb.WriteString("</span>")
+		})
+		sb.WriteString("\n")
+	}
+	sb.WriteString("</pre>")
+
+	return sb.String(), nil
+}
+
+// runs splits the row of cells into runs of cells which share
+// the same colours and calls fn with the index of the start of
+// each run
+func runs(row []cell, fn func(start int, run []cell)) {
+	start := 0
+	for i := 1; i <= len(row); i++ {
+		if i < len(row) && sameColours(row[start], row[i]) {
+			continue
+		}
+		fn(start, row[start:i])
+		start = i
+	}
+}
+
+func sameColours(a, b cell) bool {
+	if (a.bg == nil) != (b.bg == nil) {
+		return false
+	}
+	if a.bg != nil && cssColour(a.bg) != cssColour(b.bg) {
+		return false
+	}
+	return cssColour(a.clr) == cssColour(b.clr)
+}
+
+// cssColour formats the colour so it can be used in CSS,
+// colours which aren't opaque include their alpha
+func cssColour(clr color.Color) string {
+	c := color.NRGBAModel.Convert(clr).(color.NRGBA)
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3g)", c.R, c.G, c.B, float64(c.A)/255)
+}
@@ -0,0 +1,50 @@
+package ascii
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertHTML(t *testing.T) {
+	c, err := NewConverter(Grid(60, 0), CSet(CharsetLimited))
+	require.Nil(t, err)
+
+	s, err := c.ConvertHTML(testImg)
+	require.Nil(t, err)
+	assert.True(t, strings.HasPrefix(s, "<pre"))
+	assert.True(t, strings.HasSuffix(s, "</pre>"))
+
+	// The text matches the cells drawn by the image renderer
+	_, g, cs, err := c.cells(testImg, c.newProgress(context.Background(), testImg.Bounds(), 1))
+	require.Nil(t, err)
+	text := html.UnescapeString(regexp.MustCompile(`<[^>]*>`).ReplaceAllString(s, ""))
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	require.Len(t, lines, g.rows)
+	for row, line := range lines {
+		expected := make([]rune, g.cols)
+		for col := range expected {
+			expected[col] = cs[row*g.cols+col].r
+		}
+		assert.Equal(t, string(expected), line)
+	}
+
+	// Runs of the same colour share a span
+	assert.Less(t, strings.Count(s, "<span"), g.cols*g.rows)
+
+	t.Run("Escaped", func(t *testing.T) {
+		s, err := ConvertHTML(testImg, Grid(20, 0), CSet("<&>"))
+		require.Nil(t, err)
+		assert.NotContains(t, regexp.MustCompile(`<[^>]*>`).ReplaceAllString(s, ""), "<")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertHTML(nil)
+		assert.NotNil(t, err)
+	})
+}
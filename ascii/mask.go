@@ -0,0 +1,111 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+type outsideKind int
+
+const (
+	outsideSource outsideKind = iota
+	outsideColour
+	outsideCharset
+)
+
+// Outside decides how the cells outside of the mask
+// supplied with Mask are drawn
+type Outside struct {
+	kind    outsideKind
+	clr     color.Color
+	charset Charset
+}
+
+// OutsideSource draws the cells outside of the mask
+// using the pixels of the original image
+var OutsideSource = Outside{kind: outsideSource}
+
+// OutsideColour fills the cells outside of the mask
+// with the colour
+func OutsideColour(c color.Color) Outside {
+	return Outside{kind: outsideColour, clr: c}
+}
+
+// OutsideCharset draws the cells outside of the mask
+// using the runes of the charset, the runes are chosen
+// by the brightness of each cell only, so they are not
+// dithered or matched by shape
+func OutsideCharset(c Charset) Outside {
+	return Outside{kind: outsideCharset, charset: c}
+}
+
+// valid returns whether the outside has everything it needs
+func (o Outside) valid() bool {
+	switch o.kind {
+	case outsideSource:
+		return true
+	case outsideColour:
+		return o.clr != nil
+	case outsideCharset:
+		return len(o.charset) > 0
+	default:
+		return false
+	}
+}
+
+// maskThreshold is the brightness of the mask above
+// which a cell is inside of it
+const maskThreshold = 128
+
+// inside returns whether each cell of the grid is inside of
+// the mask, a cell is inside if the average brightness of the
+// mask over it is at least half. The mask is stretched over
+// the grid if their sizes are different
+func inside(mask image.Image, g grid) []bool {
+	if mask.Bounds() != g.bounds {
+		scaled := image.NewGray(g.bounds)
+		xdraw.ApproxBiLinear.Scale(scaled, g.bounds, mask, mask.Bounds(), xdraw.Src, nil)
+		mask = scaled
+	}
+
+	in := make([]bool, g.cols*g.rows)
+	s := newSampler(mask, SampleBox)
+	for row := 0; row < g.rows; row++ {
+		for col := 0; col < g.cols; col++ {
+			_, b := s.sample(g.rect(col, row))
+			in[row*g.cols+col] = b >= maskThreshold
+		}
+	}
+	return in
+}
+
+// applyMask changes the cells which are outside of the mask
+// to how the Outside draws them. Cells which are filled keep
+// their colours when the colour mode is applied afterwards
+func applyMask(cs []cell, g grid, opts *options) {
+	in := inside(opts.mask, g)
+	rs := []rune(opts.outside.charset)
+	for i := range cs {
+		if in[i] {
+			continue
+		}
+
+		switch opts.outside.kind {
+		case outsideCharset:
+			// Inverted colours have a light background
+			// so the charset is used in reverse
+			index := charIndex(cs[i].bright, len(rs))
+			if opts.colours.inverted() {
+				index = len(rs) - 1 - index
+			}
+			cs[i].r = rs[index]
+			cs[i].bg = nil
+		case outsideColour:
+			cs[i] = cell{r: ' ', clr: opts.outside.clr, bg: opts.outside.clr, bright: cs[i].bright, filled: true}
+		default:
+			cs[i] = cell{r: ' ', clr: cs[i].clr, bg: cs[i].clr, bright: cs[i].bright, filled: true}
+		}
+	}
+}
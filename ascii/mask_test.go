@@ -0,0 +1,93 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leftMask creates a mask of the test image
+// whose left half is inside of it
+func leftMask() *image.Alpha {
+	b := testImg.Bounds()
+	mask := image.NewAlpha(b)
+	left := image.Rect(b.Min.X, b.Min.Y, b.Min.X+b.Dx()/2, b.Max.Y)
+	draw.Draw(mask, left, image.Opaque, image.Point{}, draw.Src)
+	return mask
+}
+
+func TestMask(t *testing.T) {
+	t.Run("Colour", func(t *testing.T) {
+		red := color.RGBA{R: 255, A: 255}
+		text, err := ConvertText(testImg, 40, 20, CSet(CharsetLimited), Mask(leftMask(), OutsideColour(red)))
+		require.Nil(t, err)
+		for _, line := range text {
+			assert.Equal(t, strings.Repeat(" ", 20), string(line[20:]))
+		}
+
+		ascii, err := ConvertWithOpts(testImg, Mask(leftMask(), OutsideColour(red)), Colours(ColourGreyscale))
+		require.Nil(t, err)
+		assert.Equal(t, red, color.RGBAModel.Convert(ascii.At(2500, 2000)))
+	})
+
+	t.Run("Source", func(t *testing.T) {
+		ascii, err := ConvertWithOpts(testImg, Mask(leftMask(), OutsideSource))
+		require.Nil(t, err)
+		assert.Nil(t, saveImg(ascii, "convert-mask.jpg"))
+
+		// The right half is the original image and
+		// the left half is drawn with the charset
+		same, different := true, false
+		for y := 100; y < 4000; y += 37 {
+			for x := 1500; x < 2800; x += 29 {
+				same = same && color.RGBAModel.Convert(ascii.At(x, y)) == color.RGBAModel.Convert(testImg.At(x, y))
+			}
+			for x := 0; x < 1300; x += 29 {
+				different = different || color.RGBAModel.Convert(ascii.At(x, y)) != color.RGBAModel.Convert(testImg.At(x, y))
+			}
+		}
+		assert.True(t, same)
+		assert.True(t, different)
+
+		// The source is resized with the output
+		_, err = ConvertWithOpts(testImg, Mask(leftMask(), OutsideSource), Grid(80, 0), Render(RenderHalfBlock))
+		require.Nil(t, err)
+	})
+
+	t.Run("Charset", func(t *testing.T) {
+		// A greyscale mask of a different size is stretched
+		mask := image.NewGray(image.Rect(0, 0, 10, 10))
+		draw.Draw(mask, image.Rect(0, 0, 5, 10), image.White, image.Point{}, draw.Src)
+
+		mem := &Memory{}
+		for i := 0; i < 2; i++ {
+			text, err := ConvertText(testImg, 40, 20, CSet(CharsetLimited), Mask(mask, OutsideCharset("01")), Interpolate(mem))
+			require.Nil(t, err)
+			for _, line := range text {
+				assert.Empty(t, strings.Trim(string(line[20:]), "01"))
+				assert.NotEmpty(t, strings.Trim(string(line[:20]), "01"))
+			}
+		}
+
+		_, err := ConvertWithOpts(testImg, Mask(mask, OutsideCharset("01")), Render(RenderBraille))
+		require.Nil(t, err)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		invalid := []Option{
+			Mask(nil, OutsideSource),
+			Mask(image.NewAlpha(image.Rectangle{}), OutsideSource),
+			Mask(leftMask(), OutsideColour(nil)),
+			Mask(leftMask(), OutsideCharset("")),
+		}
+		for _, opt := range invalid {
+			_, err := ConvertWithOpts(testImg, opt)
+			assert.NotNil(t, err)
+		}
+	})
+}
@@ -1,13 +1,23 @@
 package ascii
 
-const interpolationWeight float64 = 0.4
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
 
-type coord struct {
-	X, Y int
-}
+const (
+	// DefaultWeight is the Weight used by a Memory
+	// which doesn't set its own
+	DefaultWeight float64 = 0.4
+	// DefaultSceneCut is the SceneCut used by a
+	// Memory which doesn't set its own
+	DefaultSceneCut float64 = 40
+)
 
-// Memory stores the brightnesses of pixels at
-// specific coordinates.
+// Memory stores the brightness of every cell of the
+// previous image which was converted with it.
 //
 // If passed in to ConvertWithOpts using the Interpolate
 // option for successive calls, go-ascii interpolates the
@@ -15,29 +25,131 @@ type coord struct {
 //
 // This is useful for converting multiple frames in a video
 // where you might want the gradual change between characters
-// to be less pronounced
+// to be less pronounced. The zero value is ready to use
 type Memory struct {
-	data map[coord]float64
+	// Weight is how much of the previous brightness of each
+	// cell is kept, between 0 and 1. The larger it is the
+	// slower the characters change, if it is 0 then the
+	// DefaultWeight is used. See TimeConstant to choose
+	// it from the frame rate of a video
+	Weight float64
+
+	// SceneCut is the mean absolute difference of the cells'
+	// brightnesses (0-255) between two images above which the
+	// second is treated as a new scene, so the Memory is reset
+	// instead of the old scene ghosting over the new one. If it
+	// is 0 the DefaultSceneCut is used and if it's negative
+	// scene cuts aren't detected
+	SceneCut float64
+
+	mu sync.Mutex
+
+	// The brightnesses of the previous image before
+	// and after they were interpolated, each has one
+	// value per cell and are nil if they're unset
+	raw, data  []float64
+	curve      []float64
+	cols, rows int
+
+	// blend is set if the current image is
+	// interpolated with the previous one
+	blend bool
+}
+
+// TimeConstant returns the Weight which makes a change in
+// brightness take effect over tau when each frame is shown
+// for the given interval, after tau the weight of the old
+// brightness has decayed to 1/e. This keeps the smoothing
+// the same regardless of the frame rate. Both durations
+// must be larger than 0, a Weight of 0 is the default
+// weight rather than no smoothing so it can't be returned
+func TimeConstant(tau, interval time.Duration) (float64, error) {
+	if tau <= 0 || interval <= 0 {
+		return 0, fmt.Errorf("time constant and interval must be larger than 0")
+	}
+	return math.Exp(-float64(interval) / float64(tau)), nil
 }
 
 // Reset clears any data the Memory may hold so that if used
 // again with Interpolate, no interpolation would occur for
 // the first call
 func (m *Memory) Reset() {
-	m.data = make(map[coord]float64)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reset()
 }
 
-func (m *Memory) interpolate(b float64, x, y int) float64 {
-	c := coord{x, y}
+func (m *Memory) reset() {
+	m.raw, m.data, m.curve = nil, nil, nil
+	m.cols, m.rows = 0, 0
+	m.blend = false
+}
 
-	// If interpolation memory exists for this pixel then interpolate the brightness
-	if oldBrightness, found := m.data[c]; found {
-		b = (b * (1 - interpolationWeight)) + (oldBrightness * interpolationWeight)
+func (m *Memory) weight() float64 {
+	if m.Weight == 0 {
+		return DefaultWeight
 	}
+	return m.Weight
+}
 
-	// Store the new brightness value in memory
-	m.data[c] = b
+// frame starts interpolating an image with cols by rows cells
+// whose brightness is given by at. If the size of the grid has
+// changed or the image is a scene cut then the Memory is reset
+// so that the image isn't interpolated
+func (m *Memory) frame(cols, rows int, at func(i int) float64) {
+	n := cols * rows
+	cut := m.cols != cols || m.rows != rows || len(m.raw) != n
+	if !cut && n > 0 {
+		threshold := m.SceneCut
+		if threshold == 0 {
+			threshold = DefaultSceneCut
+		}
+
+		if threshold > 0 {
+			var delta float64
+			for i, b := range m.raw {
+				delta += math.Abs(at(i) - b)
+			}
+			cut = delta/float64(n) > threshold
+		}
+	}
+
+	if cut {
+		m.reset()
+		m.cols, m.rows = cols, rows
+		m.raw, m.data = make([]float64, n), make([]float64, n)
+	}
+	for i := range m.raw {
+		m.raw[i] = at(i)
+	}
+	m.blend = !cut
+}
+
+// interpolate interpolates the brightness of the i-th
+// cell with its brightness in the previous image, frame
+// must have been called first
+func (m *Memory) interpolate(b float64, i int) float64 {
+	if m.blend {
+		w := m.weight()
+		b = (b * (1 - w)) + (m.data[i] * w)
+	}
 
-	// Return this new brightness
+	// Store the new brightness value in memory
+	m.data[i] = b
 	return b
 }
+
+// interpolateCurve interpolates the tone curve with the
+// one from the previous call so that its exposure doesn't
+// change suddenly between frames
+func (m *Memory) interpolateCurve(curve []float64) []float64 {
+	if len(m.curve) == len(curve) {
+		w := m.weight()
+		for i := range curve {
+			curve[i] = (curve[i] * (1 - w)) + (m.curve[i] * w)
+		}
+	}
+
+	m.curve = curve
+	return curve
+}
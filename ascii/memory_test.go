@@ -0,0 +1,88 @@
+package ascii
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	frame := func(m *Memory, bs ...float64) []float64 {
+		m.frame(len(bs), 1, func(i int) float64 { return bs[i] })
+		out := make([]float64, len(bs))
+		for i, b := range bs {
+			out[i] = m.interpolate(b, i)
+		}
+		return out
+	}
+
+	// The zero value is usable and the first
+	// image isn't interpolated
+	m := &Memory{}
+	assert.Equal(t, []float64{100, 200}, frame(m, 100, 200))
+	assert.InDeltaSlice(t, []float64{100 + 20*(1-DefaultWeight), 200}, frame(m, 120, 200), 0.001)
+
+	t.Run("Weight", func(t *testing.T) {
+		m := &Memory{Weight: 0.75}
+		frame(m, 0)
+		assert.InDeltaSlice(t, []float64{10}, frame(m, 40), 0.001)
+
+		tc := func(tau, interval time.Duration) float64 {
+			w, err := TimeConstant(tau, interval)
+			require.Nil(t, err)
+			return w
+		}
+		assert.InDelta(t, 0.3679, tc(time.Second, time.Second), 0.001)
+		assert.Less(t, tc(time.Second, time.Second/24), 1.0)
+		assert.Greater(t, tc(time.Second, time.Second/24), tc(time.Second, time.Second/12))
+
+		// A weight of 0 would be the default weight
+		_, err := TimeConstant(0, time.Second)
+		assert.NotNil(t, err)
+		_, err = TimeConstant(time.Second, 0)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("SceneCut", func(t *testing.T) {
+		m := &Memory{}
+		frame(m, 10, 10, 10)
+		assert.Equal(t, []float64{240, 240, 240}, frame(m, 240, 240, 240))
+
+		m = &Memory{SceneCut: -1}
+		frame(m, 10, 10, 10)
+		assert.NotEqual(t, []float64{240, 240, 240}, frame(m, 240, 240, 240))
+	})
+
+	t.Run("Resize", func(t *testing.T) {
+		m := &Memory{}
+		frame(m, 10, 10)
+		assert.Equal(t, []float64{20, 20, 20}, frame(m, 20, 20, 20))
+
+		m.Reset()
+		assert.Equal(t, []float64{30, 30, 30}, frame(m, 30, 30, 30))
+
+		// Grids with the same number of cells but
+		// a different shape also reset the memory
+		bs := []float64{40, 40, 40, 40, 40, 40}
+		m.frame(3, 2, func(i int) float64 { return 10 })
+		m.frame(2, 3, func(i int) float64 { return bs[i] })
+		assert.Equal(t, 40.0, m.interpolate(40, 0))
+	})
+
+	t.Run("Convert", func(t *testing.T) {
+		// Converting different sized images
+		// with the same memory is fine
+		m := &Memory{}
+		for _, cols := range []int{20, 40, 20} {
+			_, err := ConvertText(testImg, cols, 10, Interpolate(m))
+			require.Nil(t, err)
+		}
+
+		_, err := ConvertWithOpts(testImg, Interpolate(&Memory{Weight: 1}))
+		assert.NotNil(t, err)
+		_, err = ConvertWithOpts(testImg, Interpolate(&Memory{Weight: -0.5}))
+		assert.NotNil(t, err)
+	})
+}
@@ -2,15 +2,50 @@ package ascii
 
 import (
 	"fmt"
+	"image"
 
 	"golang.org/x/image/font/opentype"
 )
 
 type options struct {
-	font    *opentype.Font
-	charset Charset
-	fontPts float64
-	mem     *Memory
+	font     *opentype.Font
+	charset  Charset
+	fontPts  float64
+	mem      *Memory
+	workers  int
+	sampling Sampling
+
+	// fallbacks are used for the runes
+	// which font has no glyph for
+	fallbacks []*opentype.Font
+
+	// calibrate is set if the charset should be calibrated
+	// to the font once all options have been applied
+	calibrate bool
+	levels    int
+
+	matchShapes    bool
+	shapeThreshold float64
+
+	render RenderMode
+
+	colours     ColourMode
+	transparent bool
+
+	tone   tone
+	dither *ditherer
+
+	// The size of the output in either
+	// characters or pixels, 0 if unset
+	cols, rows    int
+	width, height int
+
+	progress func(done, total int)
+
+	// Cells outside of the mask are
+	// drawn according to outside
+	mask    image.Image
+	outside Outside
 }
 
 // Option is a function which is supplied to
@@ -18,15 +53,93 @@ type options struct {
 // settings which the convertor uses.
 //
 // Options include:
-//   * CSet -> Character set
-//   * FontPts -> Font size in pts
-//   * Font -> Font
-//   * Interpolate -> Interpolation of characters
+//   - CSet -> Character set
+//   - FontPts -> Font size in pts
+//   - Font -> Font
+//   - Fallbacks -> Fonts used for runes the font doesn't have
+//   - Interpolate -> Interpolation of characters
+//   - Workers -> Number of goroutines used to render
+//   - Sample -> Sampling of each character's pixels
+//   - Calibrate -> Calibration of the charset to the font
+//   - MatchShapes -> Choosing characters by shape
+//   - Render -> Drawing with the charset, braille or half blocks
+//   - Colours -> Colours of the characters and background
+//   - TransparentBackground -> Transparency of the background
+//   - Gamma -> Gamma correction of the brightness
+//   - BrightnessContrast -> Brightness and contrast
+//   - Equalise -> Histogram equalisation of the brightness
+//   - Stretch -> Percentile stretch of the brightness
+//   - Dither -> Error diffusion dithering
+//   - Grid -> Size of the output in characters
+//   - Size -> Size of the output in pixels
+//   - Progress -> Reporting of the rows converted
+//   - Mask -> Region of the image drawn with the charset
 type Option func(args *options) error
 
+// newOptions creates the default options and then
+// changes them according to the modifiers
+func newOptions(opts ...Option) (*options, error) {
+	o := &options{
+		font:    defaultFont,
+		charset: CharsetExtended,
+		fontPts: 14,
+		workers: 1,
+		colours: ColourOriginal,
+		tone:    defaultTone,
+	}
+
+	for _, setter := range opts {
+		if setter == nil {
+			return nil, fmt.Errorf("option supplied is nil")
+		}
+
+		err := setter(o)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Half blocks are drawn in the colours of the image
+	// so the options which change the brightness of the
+	// cells or pick their runes would be ignored
+	if o.render == RenderHalfBlock {
+		switch {
+		case o.mem != nil:
+			return nil, fmt.Errorf("half blocks cannot be interpolated")
+		case o.tone != defaultTone:
+			return nil, fmt.Errorf("half blocks cannot have their tone adjusted")
+		case o.dither != nil:
+			return nil, fmt.Errorf("half blocks cannot be dithered")
+		case o.matchShapes:
+			return nil, fmt.Errorf("half blocks cannot be matched by shape")
+		case o.colours.inverted():
+			return nil, fmt.Errorf("half blocks cannot be drawn in inverted colours")
+		}
+	}
+
+	// Calibration depends on the font and charset
+	// so it's done after every option is applied
+	if o.calibrate {
+		pf, err := parseFonts(o.font, o.fallbacks, o.fontPts)
+		if err != nil {
+			return nil, err
+		}
+		c, err := calibrateCharset(pf, o.charset, o.levels)
+		if err != nil {
+			return nil, err
+		}
+		o.charset = c
+	}
+
+	return o, nil
+}
+
 // CSet changes the character set that the convertor uses
 func CSet(c Charset) Option {
 	return func(args *options) error {
+		if len(c) == 0 {
+			return fmt.Errorf("charset cannot be empty")
+		}
 		args.charset = c
 		return nil
 	}
@@ -57,22 +170,300 @@ func Font(f *opentype.Font) Option {
 	}
 }
 
+// Fallbacks supplies the fonts which are used in order for
+// the runes of the charset which the font has no glyph for,
+// e.g. for CJK or emoji charsets. The width of each character
+// is still decided by the font, so runes from the fallbacks
+// which are wider are shrunk to fit
+func Fallbacks(fonts ...*opentype.Font) Option {
+	return func(args *options) error {
+		for _, f := range fonts {
+			if f == nil {
+				return fmt.Errorf("fallback font cannot be nil")
+			}
+		}
+		args.fallbacks = fonts
+		return nil
+	}
+}
+
 // Interpolate is able to interpolate the character used
 // if multiple ConvertWithOpts calls are used with this
 // option and if a valid Memory struct is provided.
 //
 // This makes the change in characters less pronounced
 // between successive images, this is useful if you are
-// converting successive frames of a video.
+// converting successive frames of a video. The Memory
+// resets itself when the size of the images changes or
+// when it detects a scene cut.
 func Interpolate(mem *Memory) Option {
 	return func(args *options) error {
 		if mem == nil {
 			return fmt.Errorf("memory supplied is nil")
 		}
-		if mem.data == nil {
-			mem.Reset()
+		if mem.Weight < 0 || mem.Weight >= 1 {
+			return fmt.Errorf("memory weight must be between 0 and 1")
 		}
 		args.mem = mem
 		return nil
 	}
 }
+
+// Workers changes the number of goroutines which the
+// convertor uses to render the image, the image is split
+// into bands of rows which are rendered concurrently.
+//
+// The output is identical to rendering with a single
+// worker, which is the default
+func Workers(n int) Option {
+	return func(args *options) error {
+		if n < 1 {
+			return fmt.Errorf("workers cannot be smaller than 1")
+		}
+		args.workers = n
+		return nil
+	}
+}
+
+// Sample changes how the convertor decides the brightness
+// and colour of each character from the pixels it covers.
+// By default only the top-left pixel is used, which is
+// fast but causes aliasing
+func Sample(s Sampling) Option {
+	return func(args *options) error {
+		if s < SamplePoint || s > SampleMedian {
+			return fmt.Errorf("invalid sampling: %d", s)
+		}
+		args.sampling = s
+		return nil
+	}
+}
+
+// Calibrate reorders the charset so that the brightness of
+// its runes increases monotonically for the configured font,
+// see CalibrateCharset for how levels is used
+func Calibrate(levels int) Option {
+	return func(args *options) error {
+		if levels < 0 {
+			return fmt.Errorf("levels cannot be smaller than 0")
+		}
+		args.calibrate = true
+		args.levels = levels
+		return nil
+	}
+}
+
+// MatchShapes chooses the character for each cell by comparing
+// the structure of the cell to the shapes of the charset's runes
+// as drawn by the configured font, e.g. a diagonal edge becomes
+// '/' or '\' instead of a character of the same brightness.
+//
+// Only cells whose brightness has a standard deviation of at
+// least threshold (0-255) are matched, flat cells still use the
+// charset ordered by brightness
+func MatchShapes(threshold float64) Option {
+	return func(args *options) error {
+		if threshold < 0 {
+			return fmt.Errorf("threshold cannot be smaller than 0")
+		}
+		args.matchShapes = true
+		args.shapeThreshold = threshold
+		return nil
+	}
+}
+
+// Render changes how the convertor draws each cell. Braille
+// and half blocks draw multiple samples in each cell, which
+// gives a higher resolution than the charset, and the font
+// must have glyphs for them. Half blocks are drawn in the
+// colours of the image so they can't be used with Interpolate,
+// Gamma, BrightnessContrast, Equalise, Stretch, Dither,
+// MatchShapes or ColourInverted
+func Render(m RenderMode) Option {
+	return func(args *options) error {
+		if m < RenderCharset || m > RenderHalfBlock {
+			return fmt.Errorf("invalid render mode: %d", m)
+		}
+		args.render = m
+		return nil
+	}
+}
+
+// Colours changes the colours the convertor draws the
+// characters and the background in, by default the
+// characters use the colour of the original image
+func Colours(m ColourMode) Option {
+	return func(args *options) error {
+		if !m.valid() {
+			return fmt.Errorf("invalid colour mode")
+		}
+		args.colours = m
+		return nil
+	}
+}
+
+// TransparentBackground makes the background of the
+// rendered image as transparent as the original image,
+// by default the background is always opaque
+func TransparentBackground() Option {
+	return func(args *options) error {
+		args.transparent = true
+		return nil
+	}
+}
+
+// Gamma applies gamma correction to the brightness of each
+// character before it is mapped to the charset, values
+// larger than 1 brighten the image and smaller ones darken it
+func Gamma(g float64) Option {
+	return func(args *options) error {
+		if g <= 0 {
+			return fmt.Errorf("gamma must be larger than 0")
+		}
+		args.tone.gamma = g
+		return nil
+	}
+}
+
+// BrightnessContrast changes the brightness and contrast of
+// each character before it is mapped to the charset. The
+// brightness is added to the brightness of each character,
+// where -1 is black and 1 is white, and the contrast scales
+// how far each character is from mid-grey
+func BrightnessContrast(brightness, contrast float64) Option {
+	return func(args *options) error {
+		if brightness < -1 || brightness > 1 {
+			return fmt.Errorf("brightness must be between -1 and 1")
+		}
+		if contrast < 0 {
+			return fmt.Errorf("contrast cannot be smaller than 0")
+		}
+		args.tone.brightness = brightness
+		args.tone.contrast = contrast
+		return nil
+	}
+}
+
+// Equalise spreads the brightnesses of the characters evenly
+// across the charset using histogram equalisation, so dark or
+// low contrast images use all of the charset.
+//
+// If used with Interpolate the equalisation is interpolated
+// between frames so that the exposure doesn't change suddenly
+func Equalise() Option {
+	return func(args *options) error {
+		args.tone.equalise = true
+		args.tone.stretch = false
+		return nil
+	}
+}
+
+// Stretch maps the low and high percentiles of the brightnesses
+// of the characters to the start and end of the charset, e.g.
+// Stretch(1, 99) ignores the darkest and lightest 1%.
+//
+// If used with Interpolate the stretch is interpolated between
+// frames so that the exposure doesn't change suddenly
+func Stretch(low, high float64) Option {
+	return func(args *options) error {
+		if low < 0 || high > 100 || low >= high {
+			return fmt.Errorf("percentiles must be between 0 and 100 and low must be smaller than high")
+		}
+		args.tone.stretch = true
+		args.tone.equalise = false
+		args.tone.low = low
+		args.tone.high = high
+		return nil
+	}
+}
+
+// Dither spreads the error of mapping each character's
+// brightness to a rune onto its neighbours, which removes
+// the banding caused by charsets with few runes. If
+// serpentine is set then every other row is scanned from
+// right to left which reduces directional artifacts.
+//
+// Dithering is only used when rendering with the charset
+func Dither(k DitherKernel, serpentine bool) Option {
+	return func(args *options) error {
+		if _, found := ditherKernels[k]; !found {
+			return fmt.Errorf("invalid dither kernel: %d", k)
+		}
+		args.dither = &ditherer{kernel: k, serpentine: serpentine}
+		return nil
+	}
+}
+
+// Grid sets the number of columns and rows of characters in
+// the rendered image, the source image is resampled to fit. If
+// either is 0 then it is calculated from the other so that the
+// image keeps its aspect ratio, taking into account that each
+// character is taller than it is wide.
+//
+// The rendered image is the size of the characters and is
+// no longer the same size as the source image
+func Grid(cols, rows int) Option {
+	return func(args *options) error {
+		if cols < 0 || rows < 0 || (cols == 0 && rows == 0) {
+			return fmt.Errorf("columns and rows cannot be negative and one must be larger than 0")
+		}
+		args.cols, args.rows = cols, rows
+		args.width, args.height = 0, 0
+		return nil
+	}
+}
+
+// Size sets the size of the rendered image in pixels, the
+// source image is resampled to fit. If either is 0 then it
+// is calculated from the other so that the image keeps its
+// aspect ratio
+func Size(width, height int) Option {
+	return func(args *options) error {
+		if width < 0 || height < 0 || (width == 0 && height == 0) {
+			return fmt.Errorf("width and height cannot be negative and one must be larger than 0")
+		}
+		args.width, args.height = width, height
+		args.cols, args.rows = 0, 0
+		return nil
+	}
+}
+
+// Progress calls fn as each row of characters is converted
+// with the number of rows done and the total. When rendering
+// an image every row is counted once when it's sampled and
+// once when it's drawn so total is twice the number of rows.
+//
+// Rows may be converted concurrently but calls to fn are
+// serialised and done always increases
+func Progress(fn func(done, total int)) Option {
+	return func(args *options) error {
+		if fn == nil {
+			return fmt.Errorf("progress function cannot be nil")
+		}
+		args.progress = fn
+		return nil
+	}
+}
+
+// Mask only draws the cells which are inside of the mask
+// with the charset, the cells outside of it are drawn as
+// the Outside decides. The mask is either an alpha mask or
+// a greyscale image, a cell is inside of it if the mask is
+// at least half opaque or bright over the cell. The mask is
+// stretched over the image if they are different sizes
+func Mask(mask image.Image, outside Outside) Option {
+	return func(args *options) error {
+		if mask == nil {
+			return fmt.Errorf("mask cannot be nil")
+		}
+		if mask.Bounds().Empty() {
+			return fmt.Errorf("mask cannot be empty")
+		}
+		if !outside.valid() {
+			return fmt.Errorf("invalid outside")
+		}
+		args.mask = mask
+		args.outside = outside
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package ascii
+
+import (
+	"context"
+	"sync"
+)
+
+// progress tracks how many rows of cells a conversion has
+// processed. It reports them to the callback supplied with
+// Progress and stops the conversion once its context is done
+type progress struct {
+	ctx context.Context
+	fn  func(done, total int)
+
+	mu          sync.Mutex
+	done, total int
+	err         error
+}
+
+func newProgress(ctx context.Context, fn func(done, total int), total int) *progress {
+	return &progress{ctx: ctx, fn: fn, total: total}
+}
+
+// row marks a row as processed, it returns an error if the
+// context is done and the conversion should stop. Rows may be
+// processed concurrently so the callback is called with the
+// lock held, which means done always increases
+func (p *progress) row() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	if err := p.ctx.Err(); err != nil {
+		p.err = err
+		return err
+	}
+
+	p.done++
+	if p.fn != nil {
+		p.fn(p.done, p.total)
+	}
+	return nil
+}
+
+// failed returns the error which stopped the conversion
+func (p *progress) failed() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
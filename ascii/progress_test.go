@@ -0,0 +1,67 @@
+package ascii
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ConvertContext(ctx, testImg)
+	assert.Equal(t, context.Canceled, err)
+
+	t.Run("Progress", func(t *testing.T) {
+		for _, workers := range []int{1, 4} {
+			var calls, last, total int
+			_, err := ConvertContext(context.Background(), testImg, Workers(workers), Progress(func(d, tl int) {
+				calls++
+				assert.Greater(t, d, last)
+				last, total = d, tl
+			}))
+			require.Nil(t, err)
+
+			rows := (testImg.Bounds().Dy() + 12) / 13
+			assert.Equal(t, 2*rows, total)
+			assert.Equal(t, total, last)
+			assert.Equal(t, total, calls)
+		}
+
+		var last, total int
+		_, err := ConvertText(testImg, 40, 20, Progress(func(d, tl int) {
+			last, total = d, tl
+		}))
+		require.Nil(t, err)
+		assert.Equal(t, 20, total)
+		assert.Equal(t, 20, last)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		// The conversion stops soon after the context is
+		// cancelled, even while the rows are being drawn
+		for _, stop := range []int{10, 400} {
+			ctx, cancel := context.WithCancel(context.Background())
+			var last int
+			_, err := ConvertContext(ctx, testImg, Workers(4), Progress(func(d, _ int) {
+				last = d
+				if d == stop {
+					cancel()
+				}
+			}))
+			assert.Equal(t, context.Canceled, err)
+			assert.Equal(t, stop, last)
+			cancel()
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertContext(context.Background(), nil)
+		assert.NotNil(t, err)
+
+		_, err = ConvertContext(context.Background(), testImg, Progress(nil))
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,179 @@
+package ascii
+
+import (
+	"image"
+)
+
+// RenderMode is how the cells of the image are drawn
+type RenderMode int
+
+const (
+	// RenderCharset draws each cell using a rune
+	// from the charset which matches its brightness
+	RenderCharset RenderMode = iota
+	// RenderBraille draws each cell using a braille
+	// pattern, which has a 2x4 grid of dots that are
+	// raised if the pixels under them are bright
+	RenderBraille
+	// RenderHalfBlock draws each cell using an upper
+	// half block, the top half of the cell is drawn
+	// in the foreground colour and the bottom half
+	// in the background colour
+	RenderHalfBlock
+)
+
+const (
+	brailleBlank = '⠀'
+	upperHalf    = '▀'
+	fullBlock    = '█'
+
+	// brailleThreshold is the brightness
+	// at which a braille dot is raised
+	brailleThreshold = 128
+)
+
+// brailleDots maps the position of each dot in the 2x4
+// braille grid (row-major) to the bit which raises it
+var brailleDots = [8]rune{
+	0x01, 0x08,
+	0x02, 0x10,
+	0x04, 0x20,
+	0x40, 0x80,
+}
+
+// runes returns every rune the mode draws with, these
+// must all be present in the font
+func (m RenderMode) runes(c Charset) []rune {
+	switch m {
+	case RenderBraille:
+		rs := make([]rune, 256)
+		for i := range rs {
+			rs[i] = brailleBlank + rune(i)
+		}
+		return rs
+	case RenderHalfBlock:
+		return []rune{upperHalf}
+	default:
+		return []rune(c)
+	}
+}
+
+// subRects splits the rectangle into an nx by ny grid and
+// calls fn with the index of each sub-rectangle in row-major
+// order. Every sub-rectangle covers at least one pixel, even
+// if the rectangle is smaller than the grid
+func subRects(r image.Rectangle, nx, ny int, fn func(i int, sr image.Rectangle)) {
+	for j := 0; j < ny; j++ {
+		y0 := r.Min.Y + j*r.Dy()/ny
+		y1 := r.Min.Y + (j+1)*r.Dy()/ny
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for i := 0; i < nx; i++ {
+			x0 := r.Min.X + i*r.Dx()/nx
+			x1 := r.Min.X + (i+1)*r.Dx()/nx
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			fn(j*nx+i, image.Rect(x0, y0, x1, y1))
+		}
+	}
+}
+
+// sampleBraille samples every cell of the grid for braille
+// patterns, each dot of the pattern is sampled separately.
+// The cell is coloured using the colour of the whole cell,
+// nil is returned if the conversion is stopped
+func sampleBraille(img image.Image, g grid, opts *options, p *progress) *samples {
+	cs := make([]cell, g.cols*g.rows)
+	dots := make([]float64, len(cs)*len(brailleDots))
+	bands(opts.workers, g.rows, func(start, end int) {
+		s := newSampler(img, opts.sampling)
+		for row := start; row < end; row++ {
+			for col := 0; col < g.cols; col++ {
+				i := row*g.cols + col
+				r := g.rect(col, row)
+				cs[i].clr, cs[i].bright = s.sample(r)
+				subRects(r, 2, 4, func(j int, sr image.Rectangle) {
+					_, dots[i*8+j] = s.sample(sr)
+				})
+			}
+			if p.row() != nil {
+				return
+			}
+		}
+	})
+	if p.failed() != nil {
+		return nil
+	}
+
+	return &samples{g: g, cs: cs, dots: dots}
+}
+
+// braille maps every cell to a braille pattern whose
+// dots are raised if they are bright enough
+func (s *samples) braille(opts *options) {
+	cs, dots := s.cs, s.dots
+	if opts.mem != nil {
+		opts.mem.mu.Lock()
+		defer opts.mem.mu.Unlock()
+		// Each cell has 8 dots
+		opts.mem.frame(8*s.g.cols, s.g.rows, func(i int) float64 {
+			return dots[i]
+		})
+	}
+
+	adjust := opts.tone.curve(len(dots), func(i int) float64 {
+		return dots[i]
+	}, opts.mem)
+
+	for i := range cs {
+		cs[i].r = brailleBlank
+		for j, bit := range brailleDots {
+			bright := dots[i*8+j]
+			if adjust != nil {
+				bright = adjust(bright)
+			}
+			if opts.mem != nil {
+				bright = opts.mem.interpolate(bright, i*8+j)
+			}
+
+			// Inverted colours have a light background
+			// so dots are raised for dark pixels instead
+			if (bright >= brailleThreshold) != opts.colours.inverted() {
+				cs[i].r |= bit
+			}
+		}
+	}
+}
+
+// sampleHalfBlocks maps every cell of the grid to an upper
+// half block whose foreground is the colour of the top
+// half of the cell and whose background is the colour
+// of the bottom half
+func sampleHalfBlocks(img image.Image, g grid, opts *options, p *progress) *samples {
+	cs := make([]cell, g.cols*g.rows)
+	bands(opts.workers, g.rows, func(start, end int) {
+		s := newSampler(img, opts.sampling)
+		for row := start; row < end; row++ {
+			for col := 0; col < g.cols; col++ {
+				c := &cs[row*g.cols+col]
+				c.r = upperHalf
+				subRects(g.rect(col, row), 1, 2, func(j int, sr image.Rectangle) {
+					if j == 0 {
+						c.clr, c.bright = s.sample(sr)
+					} else {
+						c.bg, _ = s.sample(sr)
+					}
+				})
+			}
+			if p.row() != nil {
+				return
+			}
+		}
+	})
+
+	return &samples{g: g, cs: cs}
+}
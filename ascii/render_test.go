@@ -0,0 +1,65 @@
+package ascii
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/opentype"
+)
+
+func TestRender(t *testing.T) {
+	t.Run("Braille", func(t *testing.T) {
+		ascii, err := ConvertWithOpts(testImg, Render(RenderBraille))
+		require.Nil(t, err)
+		assert.Nil(t, saveImg(ascii, "convert-render_braille.jpg"))
+
+		text, err := ConvertText(testImg, 80, 40, Render(RenderBraille))
+		require.Nil(t, err)
+		for _, row := range text {
+			for _, r := range row {
+				assert.True(t, r >= 0x2800 && r <= 0x28FF)
+			}
+		}
+	})
+
+	t.Run("HalfBlock", func(t *testing.T) {
+		ascii, err := ConvertWithOpts(testImg, Render(RenderHalfBlock))
+		require.Nil(t, err)
+		assert.Nil(t, saveImg(ascii, "convert-render_halfblock.jpg"))
+
+		s, err := ConvertANSI(testImg, 80, 40, ANSITrueColour, Render(RenderHalfBlock))
+		require.Nil(t, err)
+		assert.Equal(t, 80*40, strings.Count(s, "▀"))
+		assert.Contains(t, s, ";48;2;")
+
+		// Options which would be ignored are rejected
+		for _, opt := range []Option{
+			Interpolate(&Memory{}), Gamma(2), BrightnessContrast(10, 1.2), Equalise(),
+			Stretch(2, 98), Dither(DitherFloydSteinberg, false), MatchShapes(10), Colours(ColourInverted),
+		} {
+			_, err := ConvertWithOpts(testImg, Render(RenderHalfBlock), opt)
+			assert.NotNil(t, err)
+			_, err = ConvertANSI(testImg, 80, 40, ANSITrueColour, opt, Render(RenderHalfBlock))
+			assert.NotNil(t, err)
+		}
+	})
+
+	t.Run("MissingGlyphs", func(t *testing.T) {
+		f, err := opentype.Parse(gomono.TTF)
+		require.Nil(t, err)
+
+		_, err = ConvertWithOpts(testImg, Font(f), Render(RenderHalfBlock))
+		assert.Nil(t, err)
+
+		_, err = ConvertWithOpts(testImg, Font(f), Render(RenderBraille))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertWithOpts(testImg, Render(RenderMode(-1)))
+		assert.NotNil(t, err)
+	})
+}
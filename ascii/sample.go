@@ -0,0 +1,208 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Sampling is the method used to decide the brightness
+// and colour of a cell from the pixels it covers
+type Sampling int
+
+const (
+	// SamplePoint uses the pixel at the top-left of the cell
+	SamplePoint Sampling = iota
+	// SampleBox uses the average of every pixel in the cell
+	SampleBox
+	// SampleGaussian uses the average of every pixel in the cell
+	// where pixels closer to the centre have a larger weight
+	SampleGaussian
+	// SampleMedian uses the pixel with the median brightness
+	// in the cell
+	SampleMedian
+)
+
+// pixelFunc returns the colour of the pixel at (x, y) as
+// alpha-premultiplied values in the range 0-65535
+type pixelFunc func(x, y int) (r, g, b, a uint32)
+
+// pixels returns a pixelFunc for the image, the common
+// image types are read directly from their buffers which
+// is much faster than calling At for each pixel
+func pixels(img image.Image) pixelFunc {
+	switch im := img.(type) {
+	case *image.RGBA:
+		return func(x, y int) (r, g, b, a uint32) {
+			s := im.Pix[im.PixOffset(x, y):]
+			return uint32(s[0]) * 0x101, uint32(s[1]) * 0x101, uint32(s[2]) * 0x101, uint32(s[3]) * 0x101
+		}
+	case *image.NRGBA:
+		return func(x, y int) (r, g, b, a uint32) {
+			s := im.Pix[im.PixOffset(x, y):]
+			return color.NRGBA{R: s[0], G: s[1], B: s[2], A: s[3]}.RGBA()
+		}
+	case *image.YCbCr:
+		return func(x, y int) (r, g, b, a uint32) {
+			yi, ci := im.YOffset(x, y), im.COffset(x, y)
+			return color.YCbCr{Y: im.Y[yi], Cb: im.Cb[ci], Cr: im.Cr[ci]}.RGBA()
+		}
+	default:
+		return func(x, y int) (r, g, b, a uint32) {
+			return img.At(x, y).RGBA()
+		}
+	}
+}
+
+// sampler samples the cells of an image, each goroutine
+// needs its own sampler since it keeps scratch buffers
+// which are reused between cells
+type sampler struct {
+	img  image.Image
+	mode Sampling
+	px   pixelFunc
+
+	wx, wy []float64
+	median []medianPixel
+}
+
+type medianPixel struct {
+	clr    color.RGBA64
+	bright float64
+}
+
+func newSampler(img image.Image, mode Sampling) *sampler {
+	return &sampler{
+		img:  img,
+		mode: mode,
+		px:   pixels(img),
+	}
+}
+
+// sample returns the colour and brightness of the cell which
+// covers r, the brightness is in the range 0-255
+func (s *sampler) sample(r image.Rectangle) (color.Color, float64) {
+	switch s.mode {
+	case SampleBox:
+		return s.weighted(r, false)
+	case SampleGaussian:
+		return s.weighted(r, true)
+	case SampleMedian:
+		return s.medianOf(r)
+	default:
+		clr := s.img.At(r.Min.X, r.Min.Y)
+		return clr, brightness(clr)
+	}
+}
+
+// weighted returns the weighted average of the pixels in r,
+// each pixel has the same weight unless gaussian is set
+func (s *sampler) weighted(r image.Rectangle, gaussian bool) (color.Color, float64) {
+	s.wx = weights(s.wx, r.Dx(), gaussian)
+	s.wy = weights(s.wy, r.Dy(), gaussian)
+
+	var sr, sg, sb, sa, total float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		wy := s.wy[y-r.Min.Y]
+		for x := r.Min.X; x < r.Max.X; x++ {
+			w := wy * s.wx[x-r.Min.X]
+			pr, pg, pb, pa := s.px(x, y)
+			sr += w * float64(pr)
+			sg += w * float64(pg)
+			sb += w * float64(pb)
+			sa += w * float64(pa)
+			total += w
+		}
+	}
+
+	clr := color.RGBA64{
+		R: uint16(sr/total + 0.5),
+		G: uint16(sg/total + 0.5),
+		B: uint16(sb/total + 0.5),
+		A: uint16(sa/total + 0.5),
+	}
+	return clr, brightness(clr)
+}
+
+// weights fills buf with n weights for the pixels along one
+// axis of a cell, the weights either follow a gaussian which
+// is centred on the cell or they are all equal
+func weights(buf []float64, n int, gaussian bool) []float64 {
+	buf = buf[:0]
+	centre := float64(n-1) / 2
+	sigma := float64(n) / 4
+	for i := 0; i < n; i++ {
+		w := 1.0
+		if gaussian {
+			d := float64(i) - centre
+			w = math.Exp(-(d * d) / (2 * sigma * sigma))
+		}
+		buf = append(buf, w)
+	}
+	return buf
+}
+
+// medianOf returns the pixel in r with the median brightness
+func (s *sampler) medianOf(r image.Rectangle) (color.Color, float64) {
+	s.median = s.median[:0]
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			pr, pg, pb, pa := s.px(x, y)
+			clr := color.RGBA64{R: uint16(pr), G: uint16(pg), B: uint16(pb), A: uint16(pa)}
+			s.median = append(s.median, medianPixel{clr: clr, bright: brightness(clr)})
+		}
+	}
+
+	m := nth(s.median, len(s.median)/2)
+	return m.clr, m.bright
+}
+
+// nth returns the pixel which would be at index k if ps was
+// sorted by brightness, ps is partially sorted in place
+func nth(ps []medianPixel, k int) medianPixel {
+	lo, hi := 0, len(ps)-1
+	for lo < hi {
+		pivot := ps[lo+(hi-lo)/2].bright
+		i, j := lo, hi
+		for i <= j {
+			for ps[i].bright < pivot {
+				i++
+			}
+			for ps[j].bright > pivot {
+				j--
+			}
+			if i <= j {
+				ps[i], ps[j] = ps[j], ps[i]
+				i++
+				j--
+			}
+		}
+
+		switch {
+		case k <= j:
+			hi = j
+		case k >= i:
+			lo = i
+		default:
+			return ps[k]
+		}
+	}
+	return ps[k]
+}
+
+// shape returns the brightness of the pixels in r
+// downsampled to a shape
+func (s *sampler) shape(r image.Rectangle) shape {
+	var sh shape
+	subRects(r, shapeSize, shapeSize, func(i int, b image.Rectangle) {
+		var sum float64
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				pr, pg, pb, pa := s.px(x, y)
+				sum += brightness(color.RGBA64{R: uint16(pr), G: uint16(pg), B: uint16(pb), A: uint16(pa)})
+			}
+		}
+		sh[i] = sum / float64(b.Dx()*b.Dy())
+	})
+	return sh
+}
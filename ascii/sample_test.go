@@ -0,0 +1,52 @@
+package ascii
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// opaqueImage hides the concrete type of the image
+// so that the generic sampling path is used
+type opaqueImage struct {
+	image.Image
+}
+
+func TestSample(t *testing.T) {
+	rgba := image.NewRGBA(testImg.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), testImg, testImg.Bounds().Min, draw.Src)
+	nrgba := image.NewNRGBA(testImg.Bounds())
+	draw.Draw(nrgba, nrgba.Bounds(), testImg, testImg.Bounds().Min, draw.Src)
+
+	modes := map[string]Sampling{
+		"Point":    SamplePoint,
+		"Box":      SampleBox,
+		"Gaussian": SampleGaussian,
+		"Median":   SampleMedian,
+	}
+
+	for name, mode := range modes {
+		t.Run(name, func(t *testing.T) {
+			for _, img := range []image.Image{testImg, rgba, nrgba} {
+				expected, err := ConvertText(opaqueImage{img}, 120, 60, Sample(mode))
+				require.Nil(t, err)
+
+				actual, err := ConvertText(img, 120, 60, Sample(mode))
+				require.Nil(t, err)
+				assert.Equal(t, expected, actual)
+			}
+
+			ascii, err := ConvertWithOpts(testImg, Sample(mode))
+			require.Nil(t, err)
+			assert.Nil(t, saveImg(ascii, "convert-sample_"+name+".jpg"))
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertWithOpts(testImg, Sample(Sampling(-1)))
+		assert.NotNil(t, err)
+	})
+}
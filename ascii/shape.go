@@ -0,0 +1,123 @@
+package ascii
+
+import (
+	"image"
+	"math"
+)
+
+// shapeSize is the width and height of the grid which both
+// cells and glyphs are downsampled to when comparing shapes
+const shapeSize = 4
+
+// shape is the downsampled structure of a cell or glyph
+type shape [shapeSize * shapeSize]float64
+
+// normalise makes the shape zero-mean and of unit length so
+// that only its structure is compared and not its brightness.
+// The standard deviation of the shape before normalising is
+// returned, if it is 0 the shape is left unchanged
+func (s *shape) normalise() float64 {
+	var mean float64
+	for _, v := range s {
+		mean += v
+	}
+	mean /= float64(len(s))
+
+	var norm float64
+	for _, v := range s {
+		norm += (v - mean) * (v - mean)
+	}
+	if norm == 0 {
+		return 0
+	}
+	stddev := math.Sqrt(norm / float64(len(s)))
+
+	norm = math.Sqrt(norm)
+	for i := range s {
+		s[i] = (s[i] - mean) / norm
+	}
+	return stddev
+}
+
+// negate swaps the dark and light parts of the shape
+func (s *shape) negate() {
+	for i := range s {
+		s[i] = -s[i]
+	}
+}
+
+// shapes holds the shapes of the runes which cells can be
+// matched against
+type shapes struct {
+	threshold float64
+	runes     []rune
+	shapes    []shape
+}
+
+// newShapes rasterises every rune in the charset which has
+// some structure, i.e. runes such as ' ' and '█' are skipped
+// since they look the same wherever they are placed
+func newShapes(pf parsedFont, c Charset, threshold float64) *shapes {
+	// The glyph is drawn with its dot at the top-left
+	// of the cell, the box covers the line it sits on
+	m := pf.face.Metrics()
+	box := image.Rect(0, -m.Ascent.Round(), pf.width, m.Descent.Round())
+
+	ss := &shapes{threshold: threshold}
+	seen := make(map[rune]bool)
+	for _, r := range c {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+
+		g := pf.glyph(r)
+		if g.mask == nil {
+			continue
+		}
+
+		var s shape
+		subRects(box, shapeSize, shapeSize, func(i int, b image.Rectangle) {
+			var sum float64
+			overlap := b.Intersect(g.dr)
+			for y := overlap.Min.Y; y < overlap.Max.Y; y++ {
+				for x := overlap.Min.X; x < overlap.Max.X; x++ {
+					sum += float64(g.mask.AlphaAt(x-g.dr.Min.X, y-g.dr.Min.Y).A)
+				}
+			}
+			s[i] = sum / float64(b.Dx()*b.Dy())
+		})
+		if s.normalise() == 0 {
+			continue
+		}
+
+		ss.runes = append(ss.runes, r)
+		ss.shapes = append(ss.shapes, s)
+	}
+
+	return ss
+}
+
+// match returns the rune whose shape is the closest to the
+// shape of the cell. If the cell's contrast is lower than the
+// threshold then it is flat and no rune is matched
+func (ss *shapes) match(s shape) (rune, bool) {
+	if len(ss.runes) == 0 || s.normalise() < ss.threshold {
+		return 0, false
+	}
+
+	// The shapes are normalised so their dot
+	// product is their correlation
+	best, bestScore := 0, math.Inf(-1)
+	for i, gs := range ss.shapes {
+		var score float64
+		for j := range gs {
+			score += gs[j] * s[j]
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	return ss.runes[best], true
+}
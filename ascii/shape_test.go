@@ -0,0 +1,68 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchShapes(t *testing.T) {
+	t.Run("Flat", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.Gray{Y: 128}), image.Point{}, draw.Src)
+
+		expected, err := ConvertText(img, 20, 10)
+		require.Nil(t, err)
+
+		actual, err := ConvertText(img, 20, 10, MatchShapes(10))
+		require.Nil(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("Edges", func(t *testing.T) {
+		// The image is black on the left and white on the right,
+		// the boundary is in the middle of the 11th column of cells
+		img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+		draw.Draw(img, image.Rect(105, 0, 200, 200), image.White, image.Point{}, draw.Src)
+
+		text, err := ConvertText(img, 20, 10, MatchShapes(10), Sample(SampleBox))
+		require.Nil(t, err)
+
+		// The rune on the boundary should be heavier on the right
+		pf, err := parseFont(defaultFont, 14)
+		require.Nil(t, err)
+		g := pf.glyph(text[0][10])
+		var left, right int
+		for y := 0; y < g.mask.Rect.Dy(); y++ {
+			for x := 0; x < g.mask.Rect.Dx(); x++ {
+				if g.dr.Min.X+x < pf.width/2 {
+					left += int(g.mask.AlphaAt(x, y).A)
+				} else {
+					right += int(g.mask.AlphaAt(x, y).A)
+				}
+			}
+		}
+		assert.Greater(t, right, left)
+
+		for _, row := range text {
+			assert.Equal(t, '.', row[0])
+			assert.Equal(t, '$', row[19])
+			assert.Equal(t, text[0][10], row[10])
+		}
+	})
+
+	t.Run("Image", func(t *testing.T) {
+		ascii, err := ConvertWithOpts(testImg, MatchShapes(20), Sample(SampleBox))
+		require.Nil(t, err)
+		assert.Nil(t, saveImg(ascii, "convert-shapes.jpg"))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertWithOpts(testImg, MatchShapes(-1))
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,108 @@
+package ascii
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image"
+	"strings"
+
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// ConvertSVG renders the given image as a standalone SVG with a
+// <text> element for each row of characters. The default font
+// is embedded in the SVG, other fonts are referenced by their
+// family name and must be installed wherever the SVG is viewed.
+//
+// The characters are the same as those which ConvertWithOpts
+// draws when given the same Option(s)
+func ConvertSVG(img image.Image, opts ...Option) (string, error) {
+	c, err := NewConverter(opts...)
+	if err != nil {
+		return "", err
+	}
+	return c.ConvertSVG(img)
+}
+
+// ConvertSVG renders the given image as a standalone SVG,
+// see ConvertSVG for details
+func (c *Converter) ConvertSVG(img image.Image) (string, error) {
+	// Ensure image exists
+	if img == nil {
+		return "", fmt.Errorf("image cannot be nil")
+	}
+	canvas, g, cs, err := c.cells(img, c.newProgress(context.Background(), img.Bounds(), 1))
+	if err != nil {
+		return "", err
+	}
+
+	// Embed the default font, other fonts can't be
+	// embedded since their data isn't available
+	var face string
+	var families []string
+	for _, f := range append([]*opentype.Font{c.opts.font}, c.opts.fallbacks...) {
+		if f == defaultFont {
+			if face == "" {
+				face = fmt.Sprintf(`@font-face{font-family:"go-ascii";src:url(data:font/ttf;base64,%s)}`,
+					base64.StdEncoding.EncodeToString(fontBytes))
+			}
+			families = append(families, `"go-ascii"`)
+			continue
+		}
+
+		family, err := f.Name(nil, sfnt.NameIDFamily)
+		if err != nil {
+			return "", err
+		}
+		families = append(families, fmt.Sprintf(`"%s"`, html.EscapeString(family)))
+	}
+	face += fmt.Sprintf(`text{font-family:%s,monospace}`, strings.Join(families, ","))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="%d %d %d %d">`,
+		canvas.Dx(), canvas.Dy(), canvas.Min.X, canvas.Min.Y, canvas.Dx(), canvas.Dy())
+	fmt.Fprintf(&sb, `<style>%stext{font-size:%gpx;white-space:pre}</style>`, face, c.opts.fontPts)
+	if !c.opts.transparent {
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+			canvas.Min.X, canvas.Min.Y, canvas.Dx(), canvas.Dy(), cssColour(c.opts.colours.background()))
+	}
+
+	for row := 0; row < g.rows; row++ {
+		cells := cs[row*g.cols : (row+1)*g.cols]
+
+		// Draw the backgrounds of the cells first
+		// so that they're behind the text
+		runs(cells, func(start int, run []cell) {
+			if run[0].bg == nil {
+				return
+			}
+			p := g.origin(start, row)
+			fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+				p.X, p.Y, len(run)*c.cellW, c.cellH, cssColour(run[0].bg))
+		})
+
+		// Each run is positioned explicitly, so runs
+		// which are only spaces can be left out
+		dot := g.origin(0, row).Add(c.dot)
+		fmt.Fprintf(&sb, `<text y="%d">`, dot.Y)
+		runs(cells, func(start int, run []cell) {
+			var text strings.Builder
+			for _, cl := range run {
+				text.WriteRune(cl.r)
+			}
+			if strings.TrimSpace(text.String()) == "" {
+				return
+			}
+
+			fmt.Fprintf(&sb, `<tspan x="%d" fill="%s">%s</tspan>`,
+				g.origin(start, row).X+c.dot.X, cssColour(run[0].clr), html.EscapeString(text.String()))
+		})
+		sb.WriteString("</text>")
+	}
+	sb.WriteString("</svg>")
+
+	return sb.String(), nil
+}
@@ -0,0 +1,65 @@
+package ascii
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/opentype"
+)
+
+// countElements returns how many of each element the XML has,
+// it fails if the XML is malformed
+func countElements(t *testing.T, s string) map[string]int {
+	counts := make(map[string]int)
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return counts
+		}
+		require.Nil(t, err)
+		if el, ok := tok.(xml.StartElement); ok {
+			counts[el.Name.Local]++
+		}
+	}
+}
+
+func TestConvertSVG(t *testing.T) {
+	c, err := NewConverter(Grid(60, 0))
+	require.Nil(t, err)
+
+	s, err := c.ConvertSVG(testImg)
+	require.Nil(t, err)
+	assert.Contains(t, s, "@font-face")
+
+	_, _, g := c.layout(testImg.Bounds())
+	counts := countElements(t, s)
+	assert.Equal(t, 1, counts["svg"])
+	assert.Equal(t, g.rows, counts["text"])
+
+	t.Run("HalfBlock", func(t *testing.T) {
+		s, err := ConvertSVG(testImg, Grid(20, 0), Render(RenderHalfBlock))
+		require.Nil(t, err)
+		assert.Greater(t, countElements(t, s)["rect"], 1)
+	})
+
+	t.Run("ReferencedFont", func(t *testing.T) {
+		f, err := opentype.Parse(gomono.TTF)
+		require.Nil(t, err)
+
+		s, err := ConvertSVG(testImg, Grid(20, 0), Font(f))
+		require.Nil(t, err)
+		assert.NotContains(t, s, "@font-face")
+		assert.Contains(t, s, `"Go Mono"`)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertSVG(nil)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,204 @@
+package ascii
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// ANSIMode is the colour depth used by ConvertANSI
+// to colour each character
+type ANSIMode int
+
+const (
+	// ANSI16 uses the 16 standard terminal colours
+	ANSI16 ANSIMode = iota
+	// ANSI256 uses the 256 colour xterm palette
+	ANSI256
+	// ANSITrueColour uses 24-bit RGB colours
+	ANSITrueColour
+)
+
+// ansi16 is the xterm default for the 16 standard colours,
+// the first 8 are the normal colours and the last 8 are
+// their bright variants
+var ansi16 = color.Palette{
+	color.RGBA{0, 0, 0, 255},
+	color.RGBA{205, 0, 0, 255},
+	color.RGBA{0, 205, 0, 255},
+	color.RGBA{205, 205, 0, 255},
+	color.RGBA{0, 0, 238, 255},
+	color.RGBA{205, 0, 205, 255},
+	color.RGBA{0, 205, 205, 255},
+	color.RGBA{229, 229, 229, 255},
+	color.RGBA{127, 127, 127, 255},
+	color.RGBA{255, 0, 0, 255},
+	color.RGBA{0, 255, 0, 255},
+	color.RGBA{255, 255, 0, 255},
+	color.RGBA{92, 92, 255, 255},
+	color.RGBA{255, 0, 255, 255},
+	color.RGBA{0, 255, 255, 255},
+	color.RGBA{255, 255, 255, 255},
+}
+
+// ansi256 holds the colours 16-255 of the xterm palette,
+// i.e. the 6x6x6 colour cube followed by the greyscale
+// ramp. The first 16 colours are left out since terminals
+// often change them
+var ansi256 = func() color.Palette {
+	levels := []uint8{0, 95, 135, 175, 215, 255}
+
+	p := make(color.Palette, 0, 240)
+	for _, r := range levels {
+		for _, g := range levels {
+			for _, b := range levels {
+				p = append(p, color.RGBA{r, g, b, 255})
+			}
+		}
+	}
+	for i := 0; i < 24; i++ {
+		v := uint8(8 + 10*i)
+		p = append(p, color.RGBA{v, v, v, 255})
+	}
+	return p
+}()
+
+// ConvertText renders the given image as rows of runes, the
+// image is divided into exactly cols by rows characters.
+//
+// The same Option(s) as ConvertWithOpts are accepted, options
+// which only affect the rasterised image such as the font are
+// ignored
+func ConvertText(img image.Image, cols, rows int, opts ...Option) ([][]rune, error) {
+	cs, _, err := textCells(img, cols, rows, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	text := make([][]rune, rows)
+	for row := range text {
+		text[row] = make([]rune, cols)
+		for col := range text[row] {
+			text[row][col] = cs[row*cols+col].r
+		}
+	}
+
+	return text, nil
+}
+
+// ConvertANSI renders the given image as text which is
+// coloured using ANSI escape codes, each row is terminated
+// by a newline. The image is divided into exactly cols by
+// rows characters.
+//
+// The same Option(s) as ConvertWithOpts are accepted, options
+// which only affect the rasterised image such as the font are
+// ignored
+func ConvertANSI(img image.Image, cols, rows int, mode ANSIMode, opts ...Option) (string, error) {
+	if mode < ANSI16 || mode > ANSITrueColour {
+		return "", fmt.Errorf("invalid ansi mode: %d", mode)
+	}
+
+	cs, o, err := textCells(img, cols, rows, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	// The terminal's background is left as is unless
+	// the colours need a specific background
+	var bg color.Color
+	if o.colours.kind == colourMonochrome || o.colours.kind == colourInverted {
+		bg = o.colours.background()
+	}
+
+	var sb strings.Builder
+	for row := 0; row < rows; row++ {
+		// Only write the escape code when the colour changes
+		// since it is much longer than the rune itself
+		var last string
+		for _, c := range cs[row*cols : (row+1)*cols] {
+			cellBg := c.bg
+			if cellBg == nil {
+				cellBg = bg
+			}
+			code := mode.escape(c.clr, cellBg)
+			if code != last {
+				sb.WriteString(code)
+				last = code
+			}
+			sb.WriteRune(c.r)
+		}
+		sb.WriteString("\x1b[0m\n")
+	}
+
+	return sb.String(), nil
+}
+
+func textCells(img image.Image, cols, rows int, opts ...Option) ([]cell, *options, error) {
+	// Ensure image exists
+	if img == nil {
+		return nil, nil, fmt.Errorf("image cannot be nil")
+	}
+	if cols <= 0 || rows <= 0 {
+		return nil, nil, fmt.Errorf("columns and rows must be larger than 0")
+	}
+
+	// Create the options
+	o, err := newOptions(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Shapes depend on the font even though
+	// it is not used to draw the text
+	var shapes *shapes
+	if o.matchShapes && o.render == RenderCharset {
+		pf, err := parseFonts(o.font, o.fallbacks, o.fontPts)
+		if err != nil {
+			return nil, nil, err
+		}
+		shapes = newShapes(pf, o.charset, o.shapeThreshold)
+	}
+
+	cs, err := cells(img, fittedGrid(img.Bounds(), cols, rows), o, shapes,
+		newProgress(context.Background(), o.progress, rows))
+	if err != nil {
+		return nil, nil, err
+	}
+	return cs, o, nil
+}
+
+// escape returns the escape code which sets the foreground
+// colour to fg and, if it is not nil, the background to bg
+func (m ANSIMode) escape(fg, bg color.Color) string {
+	if bg == nil {
+		return fmt.Sprintf("\x1b[%sm", m.sgr(fg, false))
+	}
+	return fmt.Sprintf("\x1b[%s;%sm", m.sgr(fg, false), m.sgr(bg, true))
+}
+
+// sgr returns the parameters of the escape code which
+// sets either the foreground or background colour
+func (m ANSIMode) sgr(clr color.Color, bg bool) string {
+	// Background colours are offset from the foreground ones
+	offset := 0
+	if bg {
+		offset = 10
+	}
+
+	switch m {
+	case ANSI16:
+		i := ansi16.Index(clr)
+		if i < 8 {
+			return fmt.Sprint(30 + offset + i)
+		}
+		return fmt.Sprint(90 + offset + i - 8)
+	case ANSI256:
+		return fmt.Sprintf("%d;5;%d", 38+offset, 16+ansi256.Index(clr))
+	default:
+		c := color.RGBAModel.Convert(clr).(color.RGBA)
+		return fmt.Sprintf("%d;2;%d;%d;%d", 38+offset, c.R, c.G, c.B)
+	}
+}
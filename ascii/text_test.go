@@ -0,0 +1,51 @@
+package ascii
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertText(t *testing.T) {
+	text, err := ConvertText(testImg, 80, 40, CSet(CharsetLimited))
+	require.Nil(t, err)
+	require.Len(t, text, 40)
+
+	for _, row := range text {
+		require.Len(t, row, 80)
+		for _, r := range row {
+			assert.True(t, strings.ContainsRune(string(CharsetLimited), r))
+		}
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ConvertText(testImg, 0, 40)
+		assert.NotNil(t, err)
+
+		_, err = ConvertText(testImg, 80, -1)
+		assert.NotNil(t, err)
+
+		_, err = ConvertText(nil, 80, 40)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestConvertANSI(t *testing.T) {
+	prefixes := map[ANSIMode]string{
+		ANSI16:         "\x1b[",
+		ANSI256:        "\x1b[38;5;",
+		ANSITrueColour: "\x1b[38;2;",
+	}
+
+	for mode, prefix := range prefixes {
+		s, err := ConvertANSI(testImg, 80, 40, mode)
+		require.Nil(t, err)
+		assert.True(t, strings.HasPrefix(s, prefix))
+		assert.Equal(t, 40, strings.Count(s, "\x1b[0m\n"))
+	}
+
+	_, err := ConvertANSI(testImg, 80, 40, ANSIMode(-1))
+	assert.NotNil(t, err)
+}
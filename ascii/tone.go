@@ -0,0 +1,129 @@
+package ascii
+
+import (
+	"math"
+)
+
+// histogramBins is the number of bins the brightnesses are
+// divided into when equalising or stretching them
+const histogramBins = 256
+
+// tone holds the adjustments made to the brightness of
+// each cell before it is mapped to a rune
+type tone struct {
+	gamma                float64
+	brightness, contrast float64
+
+	// Only one of these curves is used, they're
+	// calculated from the image's histogram
+	equalise  bool
+	stretch   bool
+	low, high float64
+}
+
+var defaultTone = tone{gamma: 1, contrast: 1}
+
+// curve returns a function which adjusts the brightnesses of
+// the cells, at returns the brightness of the i-th of the n
+// cells. If the curve depends on the histogram and mem is not
+// nil then the curve is interpolated with the previous one
+// stored in the memory so that it changes smoothly between
+// frames. If the tone makes no adjustments nil is returned
+func (t tone) curve(n int, at func(i int) float64, mem *Memory) func(float64) float64 {
+	if t == defaultTone {
+		return nil
+	}
+
+	// Create the curve from the histogram
+	var lut []float64
+	if (t.equalise || t.stretch) && n > 0 {
+		var hist [histogramBins]float64
+		for i := 0; i < n; i++ {
+			hist[bin(at(i))]++
+		}
+
+		if t.equalise {
+			lut = equalise(hist, n)
+		} else {
+			lut = stretch(hist, n, t.low, t.high)
+		}
+
+		if mem != nil {
+			lut = mem.interpolateCurve(lut)
+		}
+	}
+
+	return func(b float64) float64 {
+		if lut != nil {
+			b = lut[bin(b)]
+		}
+
+		b = (b-127.5)*t.contrast + 127.5 + t.brightness*255
+		b = clamp(b, 0, 255)
+		if t.gamma != 1 {
+			b = 255 * math.Pow(b/255, 1/t.gamma)
+		}
+		return b
+	}
+}
+
+// equalise returns a curve which spreads the brightnesses
+// of the histogram evenly over the range 0-255
+func equalise(hist [histogramBins]float64, n int) []float64 {
+	lut := make([]float64, histogramBins)
+
+	// The cumulative count is centred on each bin so that
+	// an image of one brightness maps to the middle
+	var cdf float64
+	for i, count := range hist {
+		lut[i] = 255 * (cdf + count/2) / float64(n)
+		cdf += count
+	}
+	return lut
+}
+
+// stretch returns a curve which maps the low and high
+// percentiles of the histogram to 0 and 255
+func stretch(hist [histogramBins]float64, n int, low, high float64) []float64 {
+	lo, hi := percentile(hist, n, low), percentile(hist, n, high)
+
+	lut := make([]float64, histogramBins)
+	for i := range lut {
+		if hi <= lo {
+			lut[i] = float64(i)
+			continue
+		}
+		lut[i] = clamp(255*(float64(i)-lo)/(hi-lo), 0, 255)
+	}
+	return lut
+}
+
+// percentile returns the bin which p percent of the
+// histogram's brightnesses are smaller than
+func percentile(hist [histogramBins]float64, n int, p float64) float64 {
+	target := p / 100 * float64(n)
+
+	var cdf float64
+	for i, count := range hist {
+		cdf += count
+		if cdf >= target && count > 0 {
+			return float64(i)
+		}
+	}
+	return histogramBins - 1
+}
+
+// bin returns the bin of the histogram the brightness falls in
+func bin(b float64) int {
+	return int(clamp(b, 0, histogramBins-1))
+}
+
+func clamp(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
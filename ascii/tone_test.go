@@ -0,0 +1,95 @@
+package ascii
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// darkImage is a horizontal gradient from black to dark grey
+func darkImage() image.Image {
+	img := image.NewGray(image.Rect(0, 0, 256, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 256; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x / 5)})
+		}
+	}
+	return img
+}
+
+// uniqueRunes returns how many different runes the text uses
+func uniqueRunes(text [][]rune) int {
+	seen := make(map[rune]bool)
+	for _, row := range text {
+		for _, r := range row {
+			seen[r] = true
+		}
+	}
+	return len(seen)
+}
+
+func TestTone(t *testing.T) {
+	img := darkImage()
+	plain, err := ConvertText(img, 64, 4, CSet(CharsetLimited))
+	require.Nil(t, err)
+	assert.LessOrEqual(t, uniqueRunes(plain), 2)
+
+	t.Run("Equalise", func(t *testing.T) {
+		text, err := ConvertText(img, 64, 4, CSet(CharsetLimited), Equalise())
+		require.Nil(t, err)
+		assert.GreaterOrEqual(t, uniqueRunes(text), len(CharsetLimited)-1)
+	})
+
+	t.Run("Stretch", func(t *testing.T) {
+		text, err := ConvertText(img, 64, 4, CSet(CharsetLimited), Stretch(0, 100))
+		require.Nil(t, err)
+		assert.Equal(t, ' ', text[0][0])
+		assert.Equal(t, '@', text[0][63])
+	})
+
+	t.Run("Gamma", func(t *testing.T) {
+		text, err := ConvertText(img, 64, 4, CSet(CharsetLimited), Gamma(3))
+		require.Nil(t, err)
+		assert.Greater(t, uniqueRunes(text), uniqueRunes(plain))
+	})
+
+	t.Run("BrightnessContrast", func(t *testing.T) {
+		text, err := ConvertText(img, 64, 4, CSet(CharsetLimited), BrightnessContrast(1, 1))
+		require.Nil(t, err)
+		assert.Equal(t, 1, uniqueRunes(text))
+		assert.Equal(t, '@', text[0][0])
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		tn := defaultTone
+		tn.equalise = true
+		mem := &Memory{}
+
+		dark := func(i int) float64 { return 10 }
+		light := func(i int) float64 { return 240 }
+
+		// A uniform image maps to the middle of the range, so
+		// after a light frame the dark brightness would map to
+		// 0 if the previous curve wasn't interpolated with it
+		assert.InDelta(t, 127.5, tn.curve(1, dark, mem)(10), 0.001)
+		assert.InDelta(t, 127.5*DefaultWeight, tn.curve(1, light, mem)(10), 0.001)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		invalid := []Option{
+			Gamma(0),
+			BrightnessContrast(-2, 1),
+			BrightnessContrast(0, -1),
+			Stretch(50, 10),
+			Stretch(-1, 10),
+			Stretch(0, 101),
+		}
+		for _, o := range invalid {
+			_, err := ConvertText(img, 64, 4, o)
+			assert.NotNil(t, err)
+		}
+	})
+}
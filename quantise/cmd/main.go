@@ -46,7 +46,14 @@ func run(in, out string, m int, dither bool, framerate int) error {
 	}
 
 	for i := 2; i <= m; i++ {
-		p := quantise.Quantise(img, i)
+		p, err := quantise.QuantiseWithOptions(img, quantise.Colours(i))
+		if err != nil {
+			return err
+		}
+		// The image has no more colours to add
+		if len(p) < i {
+			break
+		}
 		d := quantise.Ditherer(quantise.None{})
 		if dither {
 			d = quantise.FloydSteinberg{}
@@ -0,0 +1,45 @@
+package quantise
+
+import "fmt"
+
+type options struct {
+	colours int
+}
+
+// Option modifies how an image is quantised, the options are:
+//   - Colours -> Maximum number of colours in the palette
+type Option func(args *options) error
+
+// newOptions creates the default options and then
+// changes them according to the modifiers
+func newOptions(opts ...Option) (*options, error) {
+	o := &options{
+		colours: 16,
+	}
+
+	for _, setter := range opts {
+		if setter == nil {
+			return nil, fmt.Errorf("option supplied is nil")
+		}
+
+		err := setter(o)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// Colours changes the maximum number of colours in the palette,
+// the palette has fewer colours if the image doesn't have enough
+// distinct ones. By default there are at most 16 colours
+func Colours(n int) Option {
+	return func(args *options) error {
+		if n < 1 {
+			return fmt.Errorf("palette must have at least 1 colour")
+		}
+		args.colours = n
+		return nil
+	}
+}
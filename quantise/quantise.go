@@ -2,6 +2,7 @@ package quantise
 
 import (
 	heapy "container/heap"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
@@ -201,8 +202,42 @@ func (hist histogram) initialiseColours() (*node, *heap) {
 // thresholding by fast PNN-based algorithm. Image Processing: Algorithms and
 // Systems II.
 
+// Quantise creates a palette of the given size which represents the colours
+// of the image. It returns nil if the image is nil or empty or if the size is
+// smaller than 1, use QuantiseWithOptions to find out why
 func Quantise(img image.Image, size int) color.Palette {
-	S, H := newHistogram(img).initialiseColours()
+	p, err := QuantiseWithOptions(img, Colours(size))
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+// QuantiseWithOptions creates a palette which represents the colours of the
+// image. Colours are grouped into bins before they're merged, so the palette
+// has fewer colours than requested if the image has fewer bins.
+//
+// You can pass in Option(s) to configure how the image is quantised.
+func QuantiseWithOptions(img image.Image, opts ...Option) (color.Palette, error) {
+	if img == nil {
+		return nil, fmt.Errorf("image cannot be nil")
+	}
+	if img.Bounds().Empty() {
+		return nil, fmt.Errorf("image cannot be empty")
+	}
+
+	o, err := newOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	hist := newHistogram(img)
+	size := o.colours
+	if size > len(hist) {
+		size = len(hist)
+	}
+
+	S, H := hist.initialiseColours()
 
 	m := H.Len() + 1
 	count := 0
@@ -221,7 +256,7 @@ func Quantise(img image.Image, size int) color.Palette {
 		S = S.Next
 	}
 
-	return thresholds
+	return thresholds, nil
 }
 
 func updateQuantiserState(a, b *node, H *heap, count int) {
@@ -0,0 +1,90 @@
+package quantise
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// gradient has a bin for each of its 16x16 colours
+func gradient() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 4), uint8(y * 4), 0, 255})
+		}
+	}
+	return img
+}
+
+func TestQuantiseWithOptions(t *testing.T) {
+	img := gradient()
+
+	for _, n := range []int{1, 2, 16, 256} {
+		p, err := QuantiseWithOptions(img, Colours(n))
+		if err != nil {
+			t.Fatalf("%d colours: %s", n, err)
+		}
+		if len(p) != n {
+			t.Errorf("%d colours: palette has %d colours", n, len(p))
+		}
+	}
+
+	// 16 colours are used by default
+	p, err := QuantiseWithOptions(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p) != 16 {
+		t.Errorf("default palette has %d colours", len(p))
+	}
+}
+
+func TestQuantiseFewerBins(t *testing.T) {
+	// The palette can't have more colours than the image has bins
+	p, err := QuantiseWithOptions(gradient(), Colours(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p) != 256 {
+		t.Errorf("palette has %d colours, expected 256", len(p))
+	}
+
+	// A single pixel has a single bin
+	single := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	single.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	p, err = QuantiseWithOptions(single, Colours(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p) != 1 {
+		t.Errorf("palette has %d colours, expected 1", len(p))
+	}
+}
+
+func TestQuantiseInvalid(t *testing.T) {
+	img := gradient()
+	tests := map[string]struct {
+		img  image.Image
+		opts []Option
+	}{
+		"NilImage":   {nil, nil},
+		"EmptyImage": {image.NewRGBA(image.Rect(0, 0, 0, 0)), nil},
+		"NoColours":  {img, []Option{Colours(0)}},
+		"Negative":   {img, []Option{Colours(-1)}},
+		"NilOption":  {img, []Option{nil}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := QuantiseWithOptions(tt.img, tt.opts...)
+			if err == nil {
+				t.Errorf("expected an error, got %d colours", len(p))
+			}
+		})
+	}
+
+	// Quantise returns nil instead of hanging or panicking
+	if p := Quantise(img, 0); p != nil {
+		t.Errorf("expected nil, got %d colours", len(p))
+	}
+}